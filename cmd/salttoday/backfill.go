@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/salt-today/salttoday2/internal"
+	"github.com/salt-today/salttoday2/internal/logger"
+	scrpr "github.com/salt-today/salttoday2/internal/scraper"
+)
+
+// runBackfill parses the backfill subcommand's flags and backfills a site's history
+func runBackfill(ctx context.Context, args []string) {
+	log := logger.New(ctx)
+
+	// Backfills walk deep into listings, so they're held to a gentler pace than regular scrapes
+	config := scrpr.DefaultConfig()
+	config.HostRequestsPerSecond = 1
+
+	flags := newFlagSet("backfill", "backfill -site <name> (-since <date> [-until <date>] | -from-id <id> [-to-id <id>]) [flags]", config)
+	site := flags.String("site", "", "Site to backfill, e.g. SooToday")
+	since := flags.String("since", "", "Backfill articles published on or after this date (YYYY-MM-DD)")
+	until := flags.String("until", "", "Backfill articles published on or before this date (YYYY-MM-DD), defaults to today")
+	fromID := flags.Int("from-id", 0, "Backfill articles with IDs from this one")
+	toID := flags.Int("to-id", 0, "Backfill articles with IDs up to this one, defaults to the newest")
+	flags.Parse(args)
+
+	registered, ok := internal.SiteByName(*site)
+	if !ok {
+		log.WithField("site", *site).Fatal("Unknown site, check the site registry")
+	}
+
+	// Dates are in the site's own time zone
+	opts := scrpr.BackfillOptions{SiteName: *site, FromID: *fromID, ToID: *toID}
+	var err error
+	if *since != "" {
+		if opts.Since, err = time.ParseInLocation(time.DateOnly, *since, registered.Location()); err != nil {
+			log.WithError(err).WithField("since", *since).Fatal("Unable to parse start date")
+		}
+	}
+	if *until != "" {
+		if opts.Until, err = time.ParseInLocation(time.DateOnly, *until, registered.Location()); err != nil {
+			log.WithError(err).WithField("until", *until).Fatal("Unable to parse end date")
+		}
+		opts.Until = opts.Until.AddDate(0, 0, 1).Add(-time.Second) // Include the whole day
+	}
+
+	storage := openStorage(ctx)
+
+	scraper, err := scrpr.NewScraper(ctx, config, storage)
+	if err != nil {
+		log.WithError(err).Fatal("Failed to create scraper")
+	}
+	defer scraper.Close()
+
+	if err := scraper.Backfill(ctx, opts); err != nil {
+		log.WithError(err).Error("Backfill failed, run it again with the same range to resume")
+		scraper.Close()
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/salt-today/salttoday2/internal/logger"
+	"github.com/salt-today/salttoday2/internal/metrics"
+	scrpr "github.com/salt-today/salttoday2/internal/scraper"
+)
+
+// runDaemon scrapes continuously until the process receives SIGTERM or SIGINT
+func runDaemon(ctx context.Context, args []string) {
+	log := logger.New(ctx)
+
+	config := scrpr.DefaultConfig()
+	flags := newFlagSet("daemon", "daemon [flags]", config)
+	metricsAddr := flags.String("metrics-addr", ":9100", "Address to serve /metrics on, empty to disable")
+	alertWebhook := flags.String("alert-webhook", os.Getenv("ALERT_WEBHOOK_URL"), "Webhook to post alerts such as parser drift to, alerts are only logged without one")
+	flags.Parse(args)
+	storage := openStorage(ctx)
+
+	ctx, stop := signal.NotifyContext(ctx, syscall.SIGTERM, syscall.SIGINT)
+	defer stop()
+
+	scraper, err := scrpr.NewScraper(ctx, config, storage)
+	if err != nil {
+		log.WithError(err).Fatal("Failed to create scraper")
+	}
+	defer scraper.Close()
+
+	if *alertWebhook != "" {
+		scraper.SetNotifier(scrpr.NewWebhookNotifier(*alertWebhook))
+	}
+
+	if *metricsAddr != "" {
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", metrics.Handler())
+		server := &http.Server{Addr: *metricsAddr, Handler: mux}
+		go func() {
+			if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				log.WithError(err).Error("Metrics server failed")
+			}
+		}()
+		defer server.Close()
+	}
+
+	if err := scraper.RunDaemon(ctx); err != nil {
+		log.WithError(err).Error("Daemon failed")
+		scraper.Close()
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,252 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	scrpr "github.com/salt-today/salttoday2/internal/scraper"
+	"github.com/salt-today/salttoday2/internal/store"
+	"github.com/salt-today/salttoday2/internal/store/rdb"
+)
+
+// newFlagSet creates a command's flags, starting with a flag for every ScrapingConfig field,
+// named after the field, and -log-level
+func newFlagSet(name, usage string, config *scrpr.ScrapingConfig) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: salttoday %s\n\nFlags:\n", usage)
+		flags.PrintDefaults()
+	}
+
+	configFlags(flags, config)
+	flags.Func("log-level", "Logging level: debug, info, warn or error (default info)", func(value string) error {
+		level, err := logrus.ParseLevel(value)
+		if err != nil {
+			return err
+		}
+		logrus.SetLevel(level)
+		return nil
+	})
+	return flags
+}
+
+// configFlags registers a flag for every field of the config, defaulting to its current value
+func configFlags(flags *flag.FlagSet, c *scrpr.ScrapingConfig) {
+	flags.Var(stringList{&c.Sites}, "sites", "Comma separated sites to scrape, every enabled site when empty")
+
+	// Concurrency
+	flags.IntVar(&c.MaxArticleWorkers, "max-article-workers", c.MaxArticleWorkers, "Sites scraped for articles at once")
+	flags.IntVar(&c.MaxCommentWorkers, "max-comment-workers", c.MaxCommentWorkers, "Articles scraped for comments at once")
+	flags.IntVar(&c.CommentWriteBuffer, "comment-write-buffer", c.CommentWriteBuffer, "Scraped articles that can wait to be stored before workers block")
+
+	// Timeouts
+	flags.DurationVar(&c.NavigationTimeout, "navigation-timeout", c.NavigationTimeout, "How long a browser page may take to navigate")
+	flags.DurationVar(&c.PageLoadTimeout, "page-load-timeout", c.PageLoadTimeout, "How long a page may take to load")
+
+	// Retries
+	flags.IntVar(&c.MaxRetries, "max-retries", c.MaxRetries, "Retries for a failed fetch")
+	flags.DurationVar(&c.RetryDelay, "retry-delay", c.RetryDelay, "Wait before the first retry")
+	flags.Float64Var(&c.BackoffMultiple, "backoff-multiple", c.BackoffMultiple, "How much longer each retry waits than the last")
+	flags.DurationVar(&c.MaxRetryDelay, "max-retry-delay", c.MaxRetryDelay, "Longest wait between retries")
+
+	// Politeness
+	flags.Float64Var(&c.HostRequestsPerSecond, "host-requests-per-second", c.HostRequestsPerSecond, "Requests per second to each host, 0 for unlimited")
+	flags.IntVar(&c.HostBurst, "host-burst", c.HostBurst, "Requests a host can get at once after a quiet spell")
+	flags.IntVar(&c.MaxConcurrentPerHost, "max-concurrent-per-host", c.MaxConcurrentPerHost, "In-flight requests per host, 0 for unlimited")
+	flags.DurationVar(&c.CrawlDelay, "crawl-delay", c.CrawlDelay, "Minimum gap between requests to a host")
+	flags.BoolVar(&c.RespectRobotsTxt, "respect-robots-txt", c.RespectRobotsTxt, "Skip pages disallowed by robots.txt")
+	flags.StringVar(&c.RobotsUserAgent, "robots-user-agent", c.RobotsUserAgent, "User-agent matched against robots.txt groups")
+	flags.DurationVar(&c.RobotsCacheTTL, "robots-cache-ttl", c.RobotsCacheTTL, "How long robots.txt is cached")
+
+	// Browser
+	flags.BoolVar(&c.HeadlessMode, "headless-mode", c.HeadlessMode, "Run the browser without a window")
+	flags.BoolVar(&c.DisableImages, "disable-images", c.DisableImages, "Don't load images in the browser")
+	flags.IntVar(&c.BrowserPoolSize, "browser-pool-size", c.BrowserPoolSize, "Most browser contexts open at once, 0 for one per worker")
+	flags.IntVar(&c.ContextMaxPages, "context-max-pages", c.ContextMaxPages, "Pages a browser context serves before it's replaced, 0 to reuse it forever")
+	flags.DurationVar(&c.BrowserHealthInterval, "browser-health-interval", c.BrowserHealthInterval, "How often the browser and idle contexts are checked, 0 to never check")
+
+	// Fetching and archiving
+	flags.Var(fetchModeValue{&c.DefaultFetchMode}, "default-fetch-mode", `How pages are fetched: "http" or "playwright"`)
+	flags.Var(siteFetchModes{&c.SiteFetchModes}, "site-fetch-modes", "Per site fetch modes, e.g. SooToday=http,BayToday=playwright")
+	flags.Var(archiveModeValue{&c.ArchiveMode}, "archive-mode", `Record fetched pages to the archive with "record", or scrape from it with "replay"`)
+	flags.StringVar(&c.ArchiveDir, "archive-dir", c.ArchiveDir, "Directory the archive is kept in")
+	flags.StringVar(&c.ArchiveRun, "archive-run", c.ArchiveRun, "Archived run to replay, or the name to record under (defaults to the start time)")
+
+	// Limits
+	flags.IntVar(&c.MaxCommentPages, "max-comment-pages", c.MaxCommentPages, "Pages fetched for an article's top level comments, and for each comment's replies")
+	flags.IntVar(&c.MaxReplyChain, "max-reply-chain", c.MaxReplyChain, "How deeply replies to replies are followed")
+
+	// Discovery
+	flags.BoolVar(&c.DiscoverSitemaps, "discover-sitemaps", c.DiscoverSitemaps, "Find articles in sites' sitemaps")
+	flags.Var(stringList{&c.SitemapPaths}, "sitemap-paths", "Comma separated sitemap paths")
+	flags.IntVar(&c.MaxSitemapFiles, "max-sitemap-files", c.MaxSitemapFiles, "Sitemaps fetched per site, including those listed in indexes")
+	flags.DurationVar(&c.SitemapMaxAge, "sitemap-max-age", c.SitemapMaxAge, "Skip sitemaps and entries last modified before this")
+	flags.BoolVar(&c.DiscoverFeeds, "discover-feeds", c.DiscoverFeeds, "Find articles in sites' RSS feeds")
+	flags.Var(stringList{&c.FeedPaths}, "feed-paths", "Comma separated feed paths")
+	flags.BoolVar(&c.CrawlCategories, "crawl-categories", c.CrawlCategories, "Walk category listings back in time for articles")
+	flags.IntVar(&c.CategoryMaxPages, "category-max-pages", c.CategoryMaxPages, "Listing pages per category, 0 for unlimited")
+	flags.DurationVar(&c.CategoryMaxAge, "category-max-age", c.CategoryMaxAge, "Stop at articles published before this, 0 for no limit")
+
+	// Daemon
+	flags.DurationVar(&c.HomepagePollInterval, "homepage-poll-interval", c.HomepagePollInterval, "How often the daemon checks homepages for new articles")
+	flags.DurationVar(&c.ScheduleMaxAge, "schedule-max-age", c.ScheduleMaxAge, "Stop scraping articles discovered longer ago than this")
+	flags.IntVar(&c.MaxScrapeBatch, "max-scrape-batch", c.MaxScrapeBatch, "Due articles scraped together, 0 for no limit")
+	flags.DurationVar(&c.ShutdownGracePeriod, "shutdown-grace-period", c.ShutdownGracePeriod, "How long in-flight scrapes may run after a shutdown signal")
+
+	// Adaptive scheduling
+	flags.DurationVar(&c.MinScrapeInterval, "min-scrape-interval", c.MinScrapeInterval, "Shortest time between scrapes of an article")
+	flags.DurationVar(&c.MaxScrapeInterval, "max-scrape-interval", c.MaxScrapeInterval, "Longest time between scrapes of an article")
+	flags.IntVar(&c.BusyCommentGrowth, "busy-comment-growth", c.BusyCommentGrowth, "New comments seen by one scrape that halve an article's interval")
+	flags.DurationVar(&c.RetireAfterStable, "retire-after-stable", c.RetireAfterStable, "Stop scraping articles without new comments or votes for this long, 0 to never retire")
+
+	// Parser drift and alerts
+	flags.IntVar(&c.DriftMinComments, "drift-min-comments", c.DriftMinComments, "Comments a site needs in a run before its parse quality is judged")
+	flags.Float64Var(&c.DriftMaxFallbackRate, "drift-max-fallback-rate", c.DriftMaxFallbackRate, "Share of unparseable comments that quarantines a site")
+	flags.DurationVar(&c.AlertCooldown, "alert-cooldown", c.AlertCooldown, "Minimum time between repeats of the same alert for a site")
+
+	// Coordination
+	flags.StringVar(&c.InstanceID, "instance-id", c.InstanceID, "Name for this scraper's article claims, generated when empty")
+	flags.DurationVar(&c.LeaseTTL, "lease-ttl", c.LeaseTTL, "How long a crashed scraper's articles stay claimed before others take them, 0 when it's the only scraper")
+
+	// Commenter profiles
+	flags.BoolVar(&c.ScrapeProfiles, "scrape-profiles", c.ScrapeProfiles, "Have the daemon scrape commenter profile pages for join dates, avatars and comment counts")
+	flags.DurationVar(&c.ProfileRefreshInterval, "profile-refresh-interval", c.ProfileRefreshInterval, "How long before a scraped profile is scraped again")
+	flags.DurationVar(&c.ProfilePollInterval, "profile-poll-interval", c.ProfilePollInterval, "How often the daemon looks for profiles due a scrape")
+	flags.IntVar(&c.MaxProfilesPerPoll, "max-profiles-per-poll", c.MaxProfilesPerPoll, "Profiles scraped each time the daemon looks")
+
+	// Dry runs
+	flags.StringVar(&c.DryRunOutput, "dry-run-output", c.DryRunOutput, `Don't store anything, write it as JSON lines with a diff against storage to this directory, or "-" for stdout`)
+}
+
+// openStorage connects to the database, for commands that store what they scrape, exiting when it can't
+func openStorage(ctx context.Context) store.Storage {
+	if os.Getenv("MYSQL_URL") == "" {
+		logrus.Fatal("MYSQL_URL environment variable is not set")
+	}
+	storage, err := rdb.New(ctx)
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to connect to the database")
+	}
+	return storage
+}
+
+// splitList splits a comma separated flag value, dropping empty entries
+func splitList(value string) []string {
+	var list []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// stringList is a comma separated list flag, replacing its default when set
+type stringList struct {
+	values *[]string
+}
+
+func (l stringList) String() string {
+	if l.values == nil {
+		return ""
+	}
+	return strings.Join(*l.values, ",")
+}
+
+func (l stringList) Set(value string) error {
+	*l.values = splitList(value)
+	return nil
+}
+
+// parseFetchMode checks a fetch mode named on the command line
+func parseFetchMode(value string) (scrpr.FetchMode, error) {
+	switch mode := scrpr.FetchMode(value); mode {
+	case scrpr.FetchModeHTTP, scrpr.FetchModePlaywright:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("unknown fetch mode %q, use %q or %q", value, scrpr.FetchModeHTTP, scrpr.FetchModePlaywright)
+	}
+}
+
+type fetchModeValue struct {
+	mode *scrpr.FetchMode
+}
+
+func (v fetchModeValue) String() string {
+	if v.mode == nil {
+		return ""
+	}
+	return string(*v.mode)
+}
+
+func (v fetchModeValue) Set(value string) error {
+	mode, err := parseFetchMode(value)
+	if err != nil {
+		return err
+	}
+	*v.mode = mode
+	return nil
+}
+
+// siteFetchModes is a list of site=mode pairs
+type siteFetchModes struct {
+	modes *map[string]scrpr.FetchMode
+}
+
+func (v siteFetchModes) String() string {
+	if v.modes == nil {
+		return ""
+	}
+	pairs := make([]string, 0, len(*v.modes))
+	for site, mode := range *v.modes {
+		pairs = append(pairs, site+"="+string(mode))
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+func (v siteFetchModes) Set(value string) error {
+	modes := make(map[string]scrpr.FetchMode)
+	for _, pair := range splitList(value) {
+		site, modeName, ok := strings.Cut(pair, "=")
+		if !ok {
+			return fmt.Errorf("expected site=mode, got %q", pair)
+		}
+		mode, err := parseFetchMode(modeName)
+		if err != nil {
+			return err
+		}
+		modes[site] = mode
+	}
+	*v.modes = modes
+	return nil
+}
+
+type archiveModeValue struct {
+	mode *scrpr.ArchiveMode
+}
+
+func (v archiveModeValue) String() string {
+	if v.mode == nil {
+		return ""
+	}
+	return string(*v.mode)
+}
+
+func (v archiveModeValue) Set(value string) error {
+	switch mode := scrpr.ArchiveMode(value); mode {
+	case scrpr.ArchiveModeOff, scrpr.ArchiveModeRecord, scrpr.ArchiveModeReplay:
+		*v.mode = mode
+	case "off":
+		*v.mode = scrpr.ArchiveModeOff
+	default:
+		return fmt.Errorf("unknown archive mode %q, use %q or %q", value, scrpr.ArchiveModeRecord, scrpr.ArchiveModeReplay)
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"flag"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+	"unicode"
+
+	"github.com/stretchr/testify/require"
+
+	scrpr "github.com/salt-today/salttoday2/internal/scraper"
+)
+
+// flagName turns a field name into its flag, e.g. RobotsCacheTTL into robots-cache-ttl
+func flagName(field string) string {
+	runes := []rune(field)
+	var name strings.Builder
+	for i, r := range runes {
+		startsWord := i > 0 && unicode.IsUpper(r) &&
+			(unicode.IsLower(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1])))
+		if startsWord {
+			name.WriteByte('-')
+		}
+		name.WriteRune(unicode.ToLower(r))
+	}
+	return name.String()
+}
+
+func TestConfigFlagsCoverEveryField(t *testing.T) {
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	configFlags(flags, scrpr.DefaultConfig())
+
+	fields := reflect.TypeOf(scrpr.ScrapingConfig{})
+	for i := range fields.NumField() {
+		name := flagName(fields.Field(i).Name)
+		require.NotNil(t, flags.Lookup(name), "ScrapingConfig.%s has no -%s flag", fields.Field(i).Name, name)
+	}
+}
+
+func TestConfigFlagsParse(t *testing.T) {
+	config := scrpr.DefaultConfig()
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	configFlags(flags, config)
+
+	err := flags.Parse([]string{
+		"-sites", "SooToday, BayToday",
+		"-max-comment-workers", "3",
+		"-page-load-timeout", "20s",
+		"-headless-mode=false",
+		"-default-fetch-mode", "playwright",
+		"-site-fetch-modes", "SooToday=http",
+		"-archive-mode", "replay",
+		"-feed-paths", "/feed",
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, []string{"SooToday", "BayToday"}, config.Sites)
+	require.Equal(t, 3, config.MaxCommentWorkers)
+	require.Equal(t, 20*time.Second, config.PageLoadTimeout)
+	require.False(t, config.HeadlessMode)
+	require.Equal(t, scrpr.FetchModePlaywright, config.DefaultFetchMode)
+	require.Equal(t, map[string]scrpr.FetchMode{"SooToday": scrpr.FetchModeHTTP}, config.SiteFetchModes)
+	require.Equal(t, scrpr.ArchiveModeReplay, config.ArchiveMode)
+	require.Equal(t, []string{"/feed"}, config.FeedPaths)
+
+	// Untouched fields keep their defaults
+	require.Equal(t, scrpr.DefaultConfig().MaxArticleWorkers, config.MaxArticleWorkers)
+
+	require.Error(t, flags.Parse([]string{"-default-fetch-mode", "curl"}))
+	require.Error(t, flags.Parse([]string{"-site-fetch-modes", "SooToday"}))
+}
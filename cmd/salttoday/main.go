@@ -0,0 +1,57 @@
+// Command salttoday scrapes comments from the Village Media sites into the database
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/salt-today/salttoday2/internal/logger"
+)
+
+const usage = `Usage: salttoday <command> [flags]
+
+Commands:
+  scrape articles          Discover articles on the sites' homepages
+  scrape comments          Scrape comments on recently discovered articles that are due
+  scrape all               Discover articles, then scrape comments
+  scrape article <url>     Print one article's comments without storing them
+  backfill                 Scrape a site's history by date or article ID range
+  daemon                   Scrape continuously until stopped
+  quarantine               List the sites whose parser drifted, or clear one with -clear <site>
+
+Run a command with -h to see its flags. Every command accepts the scraper's configuration
+as flags, such as -sites, -max-comment-workers, -page-load-timeout, -headless-mode and -log-level.
+`
+
+func main() {
+	startTime := time.Now()
+	ctx := context.Background()
+	log := logger.New(ctx)
+
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	command, args := os.Args[1], os.Args[2:]
+	switch command {
+	case "scrape":
+		runScrape(ctx, args)
+	case "backfill":
+		runBackfill(ctx, args)
+	case "daemon":
+		runDaemon(ctx, args)
+	case "quarantine":
+		runQuarantine(ctx, args)
+	case "help", "-h", "-help", "--help":
+		fmt.Print(usage)
+		return
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown command %q\n\n%s", command, usage)
+		os.Exit(2)
+	}
+
+	log.WithField("command", command).WithField("duration", time.Since(startTime)).Info("Complete - exiting normally")
+}
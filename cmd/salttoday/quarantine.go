@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/salt-today/salttoday2/internal/logger"
+	scrpr "github.com/salt-today/salttoday2/internal/scraper"
+)
+
+// runQuarantine lists the sites whose comments aren't being stored after their parser drifted, or
+// clears one once the parser's fixed
+func runQuarantine(ctx context.Context, args []string) {
+	log := logger.New(ctx)
+
+	flags := newFlagSet("quarantine", "quarantine [-clear <site>] [flags]", scrpr.DefaultConfig())
+	clearSite := flags.String("clear", "", "Site to take out of quarantine, e.g. SooToday")
+	flags.Parse(args)
+
+	storage := openStorage(ctx)
+
+	if *clearSite != "" {
+		if err := storage.ClearSiteQuarantine(ctx, *clearSite); err != nil {
+			log.WithError(err).WithField("site", *clearSite).Fatal("Failed to clear quarantine")
+		}
+		log.WithField("site", *clearSite).Info("Cleared quarantine, the site's comments will be stored again")
+		return
+	}
+
+	quarantined, err := storage.GetQuarantinedSites(ctx)
+	if err != nil {
+		log.WithError(err).Fatal("Failed to get quarantined sites")
+	}
+	if len(quarantined) == 0 {
+		fmt.Println("No sites are quarantined")
+		return
+	}
+
+	for _, site := range slices.Sorted(maps.Keys(quarantined)) {
+		fmt.Printf("%s: %s\n", site, quarantined[site])
+	}
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/salt-today/salttoday2/internal/logger"
+	scrpr "github.com/salt-today/salttoday2/internal/scraper"
+	"github.com/salt-today/salttoday2/internal/store"
+)
+
+// runScrape runs one of the one-shot scrapes, "articles", "comments", "all" or "article"
+func runScrape(ctx context.Context, args []string) {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	switch what := args[0]; what {
+	case "articles":
+		scrapeArticles(ctx, args[1:])
+	case "comments":
+		scrapeComments(ctx, args[1:], false)
+	case "all":
+		scrapeComments(ctx, args[1:], true)
+	case "article":
+		scrapeArticle(ctx, args[1:])
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown scrape %q\n\n%s", what, usage)
+		os.Exit(2)
+	}
+}
+
+// scrapeArticles discovers articles and stores them
+func scrapeArticles(ctx context.Context, args []string) {
+	log := logger.New(ctx)
+
+	config := scrpr.DefaultConfig()
+	flags := newFlagSet("scrape articles", "scrape articles [flags]", config)
+	flags.Parse(args)
+	storage := openStorage(ctx)
+
+	scraper, err := scrpr.NewScraper(ctx, config, storage)
+	if err != nil {
+		log.WithError(err).Fatal("Failed to create scraper")
+	}
+	defer scraper.Close()
+
+	if err := scraper.ScrapeAndStoreArticles(ctx); err != nil {
+		log.WithError(err).Error("Article scraping failed")
+		scraper.Close()
+		os.Exit(1)
+	}
+}
+
+// scrapeComments scrapes comments on the articles that are due, first discovering articles
+// when withArticles is set
+func scrapeComments(ctx context.Context, args []string, withArticles bool) {
+	log := logger.New(ctx)
+
+	config := scrpr.DefaultConfig()
+	name := "scrape comments"
+	if withArticles {
+		name = "scrape all"
+	}
+	flags := newFlagSet(name, name+" [flags]", config)
+	daysAgo := flags.Int("days-ago", 14, "Scrape articles discovered within this many days")
+	force := flags.Bool("force", false, "Scrape every article in range, even those not yet due")
+	flags.Parse(args)
+	storage := openStorage(ctx)
+
+	scraper, err := scrpr.NewScraper(ctx, config, storage)
+	if err != nil {
+		log.WithError(err).Fatal("Failed to create scraper")
+	}
+	defer scraper.Close()
+
+	if withArticles {
+		if err := scraper.ScrapeAndStoreArticles(ctx); err != nil {
+			log.WithError(err).Error("Article scraping failed (continuing to comments)")
+		}
+	}
+
+	if err := scraper.ScrapeAndStoreComments(ctx, *daysAgo, *force); err != nil {
+		log.WithError(err).Error("Comment scraping failed")
+		scraper.Close()
+		os.Exit(1)
+	}
+}
+
+// scrapeArticle prints the comments on one article, without touching the database
+func scrapeArticle(ctx context.Context, args []string) {
+	log := logger.New(ctx)
+
+	config := scrpr.DefaultConfig()
+	flags := newFlagSet("scrape article", "scrape article [flags] <url>", config)
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+	articleURL := flags.Arg(0)
+
+	articleID, err := strconv.Atoi(articleURL[strings.LastIndex(articleURL, "-")+1:])
+	if err != nil {
+		log.WithError(err).WithField("url", articleURL).Fatal("Article URL doesn't end in an article ID")
+	}
+
+	scraper, err := scrpr.NewScraper(ctx, config, nil)
+	if err != nil {
+		log.WithError(err).Fatal("Failed to create scraper")
+	}
+	defer scraper.Close()
+
+	users := make(map[int]string)
+	comments, err := scraper.ScrapeCommentsFromArticle(ctx, &store.Article{ID: articleID, Url: articleURL}, users)
+	if scrpr.ErrorKindOf(err) == scrpr.KindTruncated {
+		log.WithError(err).Warn("Only some of the comments were scraped")
+	} else if err != nil {
+		log.WithError(err).Error("Failed to scrape comments")
+		scraper.Close()
+		os.Exit(1)
+	}
+
+	log.WithField("comments_found", len(comments)).Info("Scraping completed")
+	for _, comment := range comments {
+		fmt.Printf("ID: %d\nUser: %s\nText: %s\n\n", comment.ID, users[comment.User.ID], comment.Text)
+	}
+}
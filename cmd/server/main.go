@@ -9,6 +9,7 @@ import (
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 
+	"github.com/salt-today/salttoday2/internal/metrics"
 	"github.com/salt-today/salttoday2/internal/server/handlers"
 	"github.com/salt-today/salttoday2/internal/store/rdb"
 )
@@ -16,6 +17,7 @@ import (
 func main() {
 	r := chi.NewRouter()
 	r.Use(middleware.Logger)
+	r.Use(handlers.Metrics)
 
 	storage, err := rdb.New(context.Background())
 	if err != nil {
@@ -42,6 +44,8 @@ func main() {
 	// user page
 	r.Get("/user/{userID}", handler.HandleUserPage)
 
+	r.Handle("/metrics", metrics.Handler())
+
 	r.Handle("/public/*", http.StripPrefix("/public/", http.FileServer(http.Dir("public"))))
 
 	isDeployed := os.Getenv("RAILWAY_PUBLIC_DOMAIN") != ``
@@ -0,0 +1,60 @@
+package metrics
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistryExposition(t *testing.T) {
+	r := NewRegistry()
+
+	pages := r.NewCounterVec("pages_total", "Pages fetched", "site")
+	pages.With("SooToday").Inc()
+	pages.With("SooToday").Add(2)
+	pages.With(`Bay"Today`).Inc()
+	pages.With("SooToday").Add(-5) // Ignored
+
+	pool := r.NewGaugeVec("pool_size", "Open contexts")
+	pool.With().Set(4)
+	pool.With().Dec()
+
+	latency := r.NewHistogramVec("latency_seconds", "Request latency", []float64{0.1, 1}, "route")
+	latency.With("/").Observe(0.05)
+	latency.With("/").Observe(0.5)
+	latency.With("/").Observe(3)
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	require.Equal(t, "text/plain; version=0.0.4; charset=utf-8", rec.Header().Get("Content-Type"))
+
+	expected := strings.Join([]string{
+		"# HELP latency_seconds Request latency",
+		"# TYPE latency_seconds histogram",
+		`latency_seconds_bucket{route="/",le="0.1"} 1`,
+		`latency_seconds_bucket{route="/",le="1"} 2`,
+		`latency_seconds_bucket{route="/",le="+Inf"} 3`,
+		`latency_seconds_sum{route="/"} 3.55`,
+		`latency_seconds_count{route="/"} 3`,
+		"# HELP pages_total Pages fetched",
+		"# TYPE pages_total counter",
+		`pages_total{site="Bay\"Today"} 1`,
+		`pages_total{site="SooToday"} 3`,
+		"# HELP pool_size Open contexts",
+		"# TYPE pool_size gauge",
+		"pool_size 3",
+		"",
+	}, "\n")
+	require.Equal(t, expected, rec.Body.String())
+}
+
+func TestRegistryMisuse(t *testing.T) {
+	r := NewRegistry()
+	pages := r.NewCounterVec("pages_total", "Pages fetched", "site")
+
+	require.Panics(t, func() { r.NewGaugeVec("pages_total", "Again") })
+	require.Panics(t, func() { pages.With() })
+	require.Panics(t, func() { r.NewHistogramVec("bad_seconds", "Unsorted", []float64{1, 0.1}) })
+}
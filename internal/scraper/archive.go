@@ -0,0 +1,259 @@
+package scraper
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ArchiveMode controls whether fetched pages are recorded to, or replayed from, an on-disk archive
+type ArchiveMode string
+
+const (
+	// ArchiveModeOff fetches live pages without touching the archive
+	ArchiveModeOff ArchiveMode = ""
+	// ArchiveModeRecord fetches live pages and writes every response to the archive
+	ArchiveModeRecord ArchiveMode = "record"
+	// ArchiveModeReplay serves every fetch from the archive without touching the network
+	ArchiveModeReplay ArchiveMode = "replay"
+)
+
+// archiveEntry records which stored body answered a URL
+type archiveEntry struct {
+	URL       string    `json:"url"`
+	Object    string    `json:"object"`
+	FetchedAt time.Time `json:"fetched_at"`
+}
+
+// Archive is a content-addressed store of fetched pages.
+//
+// Layout under the root directory:
+//
+//	objects/<aa>/<sha256>  response bodies, named by the hash of their content
+//	urls/<sha256>.json     the latest entry for a URL, named by the hash of the URL
+//	runs/<run>.jsonl       every entry recorded during one run, in fetch order
+type Archive struct {
+	dir string
+	run string
+
+	mu      sync.Mutex
+	runLog  *os.File
+	replays map[string]string // URL to object hash, loaded from a single run
+}
+
+// NewArchive opens (creating if needed) an archive rooted at dir
+func NewArchive(dir string) (*Archive, error) {
+	for _, sub := range []string{"objects", "urls", "runs"} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0o755); err != nil {
+			return nil, &ScrapingError{Op: "OpenArchive", Err: err}
+		}
+	}
+	return &Archive{dir: dir}, nil
+}
+
+// StartRun begins a new run manifest that records every subsequent Put
+func (a *Archive) StartRun(name string) error {
+	if err := checkRunName(name); err != nil {
+		return &ScrapingError{Op: "StartArchiveRun", Err: err}
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	f, err := os.OpenFile(filepath.Join(a.dir, "runs", name+".jsonl"), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return &ScrapingError{Op: "StartArchiveRun", Err: err}
+	}
+	a.run = name
+	a.runLog = f
+	return nil
+}
+
+// checkRunName rejects run names that would put the manifest outside the runs directory
+func checkRunName(name string) error {
+	if name == "" || name == "." || strings.Contains(name, "..") || strings.ContainsAny(name, `/\`) {
+		return fmt.Errorf("invalid run name %q, it must not be empty or contain path separators or \"..\"", name)
+	}
+	return nil
+}
+
+// ReplayRun restricts Get to the responses recorded during the named run
+func (a *Archive) ReplayRun(name string) error {
+	if err := checkRunName(name); err != nil {
+		return &ScrapingError{Op: "OpenArchiveRun", Err: err}
+	}
+
+	f, err := os.Open(filepath.Join(a.dir, "runs", name+".jsonl"))
+	if err != nil {
+		return &ScrapingError{Op: "OpenArchiveRun", Err: err}
+	}
+	defer f.Close()
+
+	replays := make(map[string]string)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var entry archiveEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return &ScrapingError{Op: "OpenArchiveRun", Kind: KindParse, Err: err}
+		}
+		// The first response for a URL is what the run originally parsed
+		if _, ok := replays[entry.URL]; !ok {
+			replays[entry.URL] = entry.Object
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return &ScrapingError{Op: "OpenArchiveRun", Err: err}
+	}
+
+	a.mu.Lock()
+	a.run = name
+	a.replays = replays
+	a.mu.Unlock()
+	return nil
+}
+
+// Put stores a response body and points the URL at it
+func (a *Archive) Put(url, body string) error {
+	object := hashString(body)
+	objectPath := a.objectPath(object)
+
+	// Identical bodies share one object, so only write it the first time
+	if _, err := os.Stat(objectPath); errors.Is(err, os.ErrNotExist) {
+		if err := os.MkdirAll(filepath.Dir(objectPath), 0o755); err != nil {
+			return &ScrapingError{Op: "ArchivePut", URL: url, Err: err}
+		}
+		if err := writeFileAtomic(objectPath, []byte(body)); err != nil {
+			return &ScrapingError{Op: "ArchivePut", URL: url, Err: err}
+		}
+	}
+
+	entry, err := json.Marshal(archiveEntry{URL: url, Object: object, FetchedAt: time.Now()})
+	if err != nil {
+		return &ScrapingError{Op: "ArchivePut", URL: url, Err: err}
+	}
+	if err := writeFileAtomic(a.urlPath(url), entry); err != nil {
+		return &ScrapingError{Op: "ArchivePut", URL: url, Err: err}
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.runLog != nil {
+		if _, err := a.runLog.Write(append(entry, '\n')); err != nil {
+			return &ScrapingError{Op: "ArchivePut", URL: url, Err: err}
+		}
+	}
+	return nil
+}
+
+// Get returns the archived body for a URL
+func (a *Archive) Get(url string) (string, error) {
+	a.mu.Lock()
+	object, ok := a.replays[url]
+	replayingRun := a.replays != nil
+	a.mu.Unlock()
+
+	if !replayingRun {
+		data, err := os.ReadFile(a.urlPath(url))
+		if err != nil {
+			return "", &ScrapingError{Op: "ArchiveGet", URL: url, Kind: KindNotFound, Err: errors.New("not in archive")}
+		}
+		var entry archiveEntry
+		if err := json.Unmarshal(data, &entry); err != nil {
+			return "", &ScrapingError{Op: "ArchiveGet", URL: url, Kind: KindParse, Err: err}
+		}
+		object, ok = entry.Object, true
+	}
+	if !ok {
+		return "", &ScrapingError{Op: "ArchiveGet", URL: url, Kind: KindNotFound, Err: fmt.Errorf("not in archived run %s", a.run)}
+	}
+
+	body, err := os.ReadFile(a.objectPath(object))
+	if err != nil {
+		return "", &ScrapingError{Op: "ArchiveGet", URL: url, Err: err}
+	}
+	return string(body), nil
+}
+
+// Close flushes the current run manifest
+func (a *Archive) Close() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.runLog == nil {
+		return nil
+	}
+	err := a.runLog.Close()
+	a.runLog = nil
+	return err
+}
+
+func (a *Archive) objectPath(object string) string {
+	return filepath.Join(a.dir, "objects", object[:2], object)
+}
+
+func (a *Archive) urlPath(url string) string {
+	return filepath.Join(a.dir, "urls", hashString(url)+".json")
+}
+
+// ArchiveFetcher records responses from another fetcher, or replays them from the archive
+type ArchiveFetcher struct {
+	next    Fetcher
+	archive *Archive
+	mode    ArchiveMode
+}
+
+// NewArchiveFetcher wraps next so that its responses are recorded or replayed according to mode
+func NewArchiveFetcher(next Fetcher, archive *Archive, mode ArchiveMode) *ArchiveFetcher {
+	return &ArchiveFetcher{next: next, archive: archive, mode: mode}
+}
+
+// Fetch returns the archived response in replay mode, otherwise fetches and records it
+func (f *ArchiveFetcher) Fetch(ctx context.Context, url string, timeout time.Duration) (string, error) {
+	if f.mode == ArchiveModeReplay {
+		return f.archive.Get(url)
+	}
+
+	content, err := f.next.Fetch(ctx, url, timeout)
+	if err != nil {
+		return "", err
+	}
+
+	if f.mode == ArchiveModeRecord {
+		if err := f.archive.Put(url, content); err != nil {
+			return "", err
+		}
+	}
+	return content, nil
+}
+
+func hashString(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+// writeFileAtomic writes data to a temporary file and renames it into place
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,65 @@
+package scraper
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/salt-today/salttoday2/internal/store"
+)
+
+func TestArchiveRecordAndReplay(t *testing.T) {
+	server := newFixtureServer(t, "villagemedia")
+	dir := t.TempDir()
+	article := &store.Article{
+		ID:            1000001,
+		Url:           server.URL + "/local-news/city-council-approves-new-budget-1000001",
+		DiscoveryTime: time.Now(),
+	}
+
+	recorder := newTestScraper(t, newFakeStorage(article))
+	recorder.config.ArchiveDir = dir
+	recorder.config.ArchiveMode = ArchiveModeRecord
+	recorder.config.ArchiveRun = "first"
+	require.NoError(t, recorder.openArchive())
+
+	recorded, err := recorder.ScrapeCommentsFromArticle(context.Background(), article, make(map[int]string))
+	require.NoError(t, err)
+	require.NoError(t, recorder.Close())
+
+	// Nothing is served live once the archive has the run
+	server.Close()
+
+	replayer := newTestScraper(t, newFakeStorage(article))
+	replayer.config.ArchiveDir = dir
+	replayer.config.ArchiveMode = ArchiveModeReplay
+	replayer.config.ArchiveRun = "first"
+	require.NoError(t, replayer.openArchive())
+
+	replayed, err := replayer.ScrapeCommentsFromArticle(context.Background(), article, make(map[int]string))
+	require.NoError(t, err)
+	require.Equal(t, recorded, replayed)
+
+	_, err = replayer.archive.Get(server.URL + "/not-recorded")
+	require.Error(t, err)
+}
+
+func TestArchiveRunNames(t *testing.T) {
+	dir := t.TempDir()
+	archive, err := NewArchive(dir)
+	require.NoError(t, err)
+	t.Cleanup(func() { archive.Close() })
+
+	for _, name := range []string{"", ".", "..", "../escaped", "nested/run", `..\escaped`, "/tmp/run"} {
+		require.Error(t, archive.StartRun(name), name)
+		require.Error(t, archive.ReplayRun(name), name)
+	}
+	require.NoFileExists(t, filepath.Join(dir, "escaped.jsonl"))
+
+	require.NoError(t, archive.StartRun("2024-05-01.nightly"))
+	require.FileExists(t, filepath.Join(dir, "runs", "2024-05-01.nightly.jsonl"))
+	require.NoError(t, archive.ReplayRun("2024-05-01.nightly"))
+}
@@ -0,0 +1,219 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/salt-today/salttoday2/internal"
+	"github.com/salt-today/salttoday2/internal/logger"
+	"github.com/salt-today/salttoday2/internal/store"
+)
+
+// BackfillOptions selects the historical articles of a site to backfill, either by
+// publish date or by article ID
+type BackfillOptions struct {
+	SiteName string
+	Since    time.Time
+	Until    time.Time // Zero means up to now
+	FromID   int
+	ToID     int // 0 means up to the newest article
+}
+
+// validate checks that exactly one kind of range was given
+func (o BackfillOptions) validate() error {
+	if _, ok := internal.SiteByName(o.SiteName); !ok {
+		return fmt.Errorf("unknown site %q", o.SiteName)
+	}
+
+	byDate := !o.Since.IsZero() || !o.Until.IsZero()
+	byID := o.FromID > 0 || o.ToID > 0
+	switch {
+	case byDate && byID:
+		return errors.New("backfill by either date or article ID, not both")
+	case byDate && o.Since.IsZero():
+		return errors.New("a date backfill needs a start date")
+	case byDate && !o.Until.IsZero() && o.Until.Before(o.Since):
+		return errors.New("backfill end date is before its start date")
+	case byID && o.FromID <= 0:
+		return errors.New("an article ID backfill needs a starting ID")
+	case byID && o.ToID > 0 && o.ToID < o.FromID:
+		return errors.New("backfill end ID is below its starting ID")
+	case !byDate && !byID:
+		return errors.New("backfill needs a date or article ID range")
+	}
+	return nil
+}
+
+// params identifies the range in the run ledger, so the same backfill can be resumed
+func (o BackfillOptions) params() string {
+	if o.FromID > 0 {
+		return fmt.Sprintf("ids=%d-%d", o.FromID, o.ToID)
+	}
+
+	until := ""
+	if !o.Until.IsZero() {
+		until = o.Until.UTC().Format(time.RFC3339)
+	}
+	return fmt.Sprintf("dates=%s/%s", o.Since.UTC().Format(time.RFC3339), until)
+}
+
+// Backfill crawls a registered site's category listings, whether or not it's enabled yet,
+// back through the given range, storing each article with its real publish time along with
+// its comments. Progress is recorded in the run ledger after every category, and an
+// unfinished run for the same range is resumed.
+func (s *Scraper) Backfill(ctx context.Context, opts BackfillOptions) error {
+	if err := opts.validate(); err != nil {
+		return err
+	}
+	logEntry := logger.New(ctx).WithFields(logrus.Fields{
+		"operation": "backfill",
+		"site":      opts.SiteName,
+		"range":     opts.params(),
+	})
+
+	storage, err := s.requireStorage()
+	if err != nil {
+		return err
+	}
+
+	run, err := s.startBackfillRun(ctx, storage, opts)
+	if err != nil {
+		return &ScrapingError{Op: "StartRun", Kind: KindStorage, Err: err}
+	}
+	logEntry = logEntry.WithField("run_id", run.ID)
+
+	// Categories are crawled in a fixed order, so the checkpoint is the last one completed
+	remaining := articleCategories
+	if i := slices.Index(articleCategories, run.Checkpoint); i >= 0 {
+		remaining = articleCategories[i+1:]
+		logEntry.WithField("checkpoint", run.Checkpoint).Info("Resuming backfill")
+	}
+
+	crawl := CategoryCrawl{Since: opts.Since, Until: opts.Until, FromID: opts.FromID, ToID: opts.ToID}
+	site, _ := internal.SiteByName(opts.SiteName)
+	siteURL := site.URL
+	for _, category := range remaining {
+		_, err := s.crawlCategory(ctx, siteURL, category, crawl, func(page int, articles map[int]*store.Article) error {
+			return s.backfillArticles(ctx, storage, run, opts.SiteName, articles)
+		})
+		if err != nil {
+			s.finishRun(ctx, storage, run, err)
+			return err
+		}
+
+		run.Checkpoint = category
+		run.UpdateTime = time.Now()
+		if err := storage.UpdateScrapeRun(ctx, run); err != nil {
+			return &ScrapingError{Op: "UpdateRun", Kind: KindStorage, Err: err}
+		}
+	}
+
+	s.finishRun(ctx, storage, run, nil)
+	logEntry.WithFields(logrus.Fields{
+		"articles_found": run.ArticlesFound,
+		"comments_found": run.CommentsFound,
+	}).Info("Backfill completed")
+	return nil
+}
+
+// startBackfillRun resumes the latest unfinished run for the same range, or records a new one
+func (s *Scraper) startBackfillRun(ctx context.Context, storage store.Storage, opts BackfillOptions) (*store.ScrapeRun, error) {
+	runs, err := storage.GetScrapeRuns(ctx, &store.ScrapeRunQueryOptions{
+		Kind:       store.RunKindBackfill,
+		SiteName:   opts.SiteName,
+		Params:     opts.params(),
+		Unfinished: true,
+	})
+	var noResults *store.NoQueryResultsError
+	if err != nil && !errors.As(err, &noResults) {
+		return nil, err
+	}
+
+	if len(runs) > 0 {
+		run := runs[0]
+		run.Status = store.RunStatusRunning
+		run.Error = ""
+		run.UpdateTime = time.Now()
+		return run, storage.UpdateScrapeRun(ctx, run)
+	}
+
+	now := time.Now()
+	run := &store.ScrapeRun{
+		Kind:       store.RunKindBackfill,
+		SiteName:   opts.SiteName,
+		Params:     opts.params(),
+		Status:     store.RunStatusRunning,
+		StartTime:  now,
+		UpdateTime: now,
+	}
+	return run, storage.AddScrapeRun(ctx, run)
+}
+
+// backfillArticles stores one listing page of articles and their comments, skipping
+// any that this run already scraped before it was interrupted
+func (s *Scraper) backfillArticles(ctx context.Context, storage store.Storage, run *store.ScrapeRun, siteName string, found map[int]*store.Article) error {
+	if len(found) == 0 {
+		return nil
+	}
+
+	ids := make([]int, 0, len(found))
+	for id := range found {
+		ids = append(ids, id)
+	}
+	existing, err := storage.GetArticles(ctx, ids...)
+	var noResults *store.NoQueryResultsError
+	if err != nil && !errors.As(err, &noResults) {
+		return &ScrapingError{Op: "GetArticles", Kind: KindStorage, Err: err}
+	}
+	for _, article := range existing {
+		if !article.LastScrapeTime.Before(run.StartTime.Truncate(time.Second)) {
+			delete(found, article.ID)
+		}
+	}
+	if len(found) == 0 {
+		return nil
+	}
+
+	articles := make([]*store.Article, 0, len(found))
+	for _, article := range found {
+		article.SiteName = siteName
+		articles = append(articles, article)
+	}
+	if err := storage.AddArticles(ctx, articles...); err != nil {
+		return &ScrapingError{Op: "StoreArticles", Kind: KindStorage, Err: err}
+	}
+
+	summary, err := s.scrapeAndStoreCommentsConcurrently(ctx, storage, articles)
+	if err != nil {
+		return err
+	}
+
+	run.ArticlesFound += len(articles)
+	run.CommentsFound += summary.comments
+	run.UpdateTime = time.Now()
+	if err := storage.UpdateScrapeRun(ctx, run); err != nil {
+		return &ScrapingError{Op: "UpdateRun", Kind: KindStorage, Err: err}
+	}
+	return nil
+}
+
+// finishRun marks a run completed, or failed with the given error
+func (s *Scraper) finishRun(ctx context.Context, storage store.Storage, run *store.ScrapeRun, runErr error) {
+	run.Status = store.RunStatusCompleted
+	run.FinishTime = time.Now()
+	if runErr != nil {
+		run.Status = store.RunStatusFailed
+		run.Error = runErr.Error()
+		run.FinishTime = time.Time{}
+	}
+	run.UpdateTime = time.Now()
+
+	if err := storage.UpdateScrapeRun(ctx, run); err != nil {
+		logger.New(ctx).WithError(err).WithField("run_id", run.ID).Error("Failed to record scrape run result")
+	}
+}
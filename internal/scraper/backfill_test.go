@@ -0,0 +1,76 @@
+package scraper
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/salt-today/salttoday2/internal/store"
+)
+
+func TestBackfillOptionsValidate(t *testing.T) {
+	since := time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)
+
+	require.NoError(t, BackfillOptions{SiteName: "SooToday", Since: since}.validate())
+	require.NoError(t, BackfillOptions{SiteName: "SooToday", FromID: 100, ToID: 200}.validate())
+	require.Error(t, BackfillOptions{SiteName: "NowhereToday", Since: since}.validate())
+	require.Error(t, BackfillOptions{SiteName: "SooToday"}.validate())
+	require.Error(t, BackfillOptions{SiteName: "SooToday", Since: since, FromID: 100}.validate())
+	require.Error(t, BackfillOptions{SiteName: "SooToday", Since: since, Until: since.Add(-time.Hour)}.validate())
+	require.Error(t, BackfillOptions{SiteName: "SooToday", FromID: 200, ToID: 100}.validate())
+}
+
+func TestBackfill(t *testing.T) {
+	server := newFixtureServer(t, "villagemedia")
+	siteName := withFixtureSite(t, server.URL)
+	storage := newFakeStorage()
+	s := newTestScraper(t, storage)
+
+	opts := BackfillOptions{SiteName: siteName, Since: time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)}
+	require.NoError(t, s.Backfill(context.Background(), opts))
+
+	require.Len(t, storage.articles, 3)
+	for _, id := range []int{1000001, 1000020, 1000021} {
+		require.Equal(t, siteName, storage.articles[id].SiteName)
+		require.Contains(t, storage.scrapedAt, id)
+	}
+	// Articles keep their publish time rather than the time of the backfill
+	require.Equal(t, time.Date(2024, 4, 15, 15, 0, 0, 0, time.UTC), storage.articles[1000021].DiscoveryTime.UTC())
+	require.NotEmpty(t, storage.commentIDs())
+
+	require.Len(t, storage.runs, 1)
+	run := storage.runs[0]
+	require.Equal(t, store.RunKindBackfill, run.Kind)
+	require.Equal(t, store.RunStatusCompleted, run.Status)
+	require.Equal(t, articleCategories[len(articleCategories)-1], run.Checkpoint)
+	require.Equal(t, 3, run.ArticlesFound)
+	require.False(t, run.FinishTime.IsZero())
+}
+
+func TestBackfillResumesFromCheckpoint(t *testing.T) {
+	server := newFixtureServer(t, "villagemedia")
+	siteName := withFixtureSite(t, server.URL)
+	storage := newFakeStorage()
+	s := newTestScraper(t, storage)
+
+	opts := BackfillOptions{SiteName: siteName, FromID: 1000001}
+	interrupted := &store.ScrapeRun{
+		Kind:       store.RunKindBackfill,
+		SiteName:   siteName,
+		Params:     opts.params(),
+		Status:     store.RunStatusFailed,
+		Checkpoint: "/local-news/",
+		StartTime:  time.Now().Add(-time.Hour),
+	}
+	require.NoError(t, storage.AddScrapeRun(context.Background(), interrupted))
+
+	require.NoError(t, s.Backfill(context.Background(), opts))
+
+	// Categories up to the checkpoint aren't crawled again
+	require.Zero(t, server.requestCount("/local-news"))
+	require.Zero(t, server.requestCount("/good-morning"))
+	require.Len(t, storage.runs, 1)
+	require.Equal(t, store.RunStatusCompleted, storage.runs[0].Status)
+}
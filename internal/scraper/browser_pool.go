@@ -2,102 +2,423 @@ package scraper
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"sync"
 	"time"
 
 	"github.com/playwright-community/playwright-go"
+	"github.com/sirupsen/logrus"
+
 	"github.com/salt-today/salttoday2/internal/logger"
 )
 
-// BrowserPool manages a pool of reusable browser contexts for performance
+// BrowserPool manages a capped pool of reusable browser contexts, replacing contexts that have
+// served too many pages or stopped working, and relaunching the browser if it goes away
 type BrowserPool struct {
-	browser playwright.Browser
-	config  *ScrapingConfig
+	launch BrowserLauncher
+	config *ScrapingConfig
+	log    *logrus.Entry
+
+	// Pool management, a slot is held for every open context
+	slots chan struct{}
+	idle  chan *pooledContext
 
-	// Pool management
-	contexts chan playwright.BrowserContext
+	launchMu sync.Mutex // Held while the browser is checked or relaunched
 	mu       sync.Mutex
+	browser  playwright.Browser
 	closed   bool
+	stats    BrowserPoolStats
+
+	stopHealth chan struct{}
+	healthDone chan struct{}
 }
 
-// NewBrowserPool creates a new browser pool with the given configuration
-func NewBrowserPool(ctx context.Context, browser playwright.Browser, config *ScrapingConfig) *BrowserPool {
+// BrowserLauncher starts the pool's browser, and is called again whenever it disconnects
+type BrowserLauncher func() (playwright.Browser, error)
+
+// BrowserPoolStats describe a browser pool, for diagnostics
+type BrowserPoolStats struct {
+	Size       int // Most contexts open at once
+	Open       int
+	Idle       int
+	InUse      int
+	Created    int // Contexts created over the pool's lifetime
+	Recycled   int // Contexts closed after serving ContextMaxPages pages
+	Unhealthy  int // Contexts closed after failing, failing a health check or outliving their browser
+	Pages      int // Pages opened
+	Relaunches int // Times the browser was relaunched after disconnecting
+}
+
+// Why a context was closed
+const (
+	closeRecycled  = "recycled"
+	closeUnhealthy = "unhealthy"
+	closePool      = "closed"
+)
+
+// pooledContext is a browser context along with what the pool knows about it.
+// Only whoever holds it touches pages and broken.
+type pooledContext struct {
+	playwright.BrowserContext
+	browser playwright.Browser // The browser it was created in
+	pages   int
+	broken  bool // A page in it failed in a way that points at the browser
+}
+
+// NewBrowserPool launches a browser and creates a pool of contexts in it
+func NewBrowserPool(ctx context.Context, launch BrowserLauncher, config *ScrapingConfig) (*BrowserPool, error) {
+	size := config.browserPoolSize()
 	pool := &BrowserPool{
-		browser:  browser,
-		config:   config,
-		contexts: make(chan playwright.BrowserContext, config.MaxArticleWorkers+config.MaxCommentWorkers),
+		launch:     launch,
+		config:     config,
+		log:        logger.New(ctx).WithField("component", "browser-pool"),
+		slots:      make(chan struct{}, size),
+		idle:       make(chan *pooledContext, size),
+		stats:      BrowserPoolStats{Size: size},
+		stopHealth: make(chan struct{}),
+		healthDone: make(chan struct{}),
+	}
+	if _, err := pool.currentBrowser(); err != nil {
+		return nil, err
 	}
 
 	// Pre-populate the pool with some contexts
-	initialSize := min(5, config.MaxArticleWorkers+config.MaxCommentWorkers)
-	for range initialSize {
-		if ctx, err := pool.createContext(); err == nil {
-			pool.contexts <- ctx
+	for range min(5, size) {
+		pool.slots <- struct{}{}
+		browserCtx, err := pool.createContext()
+		if err != nil {
+			<-pool.slots
+			break
 		}
+		pool.idle <- browserCtx
+		browserContexts.With("idle").Inc()
 	}
 
-	return pool
+	if config.BrowserHealthInterval > 0 {
+		go pool.checkHealthEvery(config.BrowserHealthInterval)
+	} else {
+		close(pool.healthDone)
+	}
+	return pool, nil
 }
 
-// GetContext gets a browser context from the pool or creates a new one
+// GetContext gets an idle browser context from the pool, or creates one if the pool isn't full,
+// otherwise waiting for one to be returned
 func (bp *BrowserPool) GetContext(ctx context.Context) (playwright.BrowserContext, error) {
-	bp.mu.Lock()
-	if bp.closed {
+	start := time.Now()
+	defer func() { browserWait.With().Observe(time.Since(start).Seconds()) }()
+
+	for {
+		if bp.isClosed() {
+			return nil, errPoolClosed("GetContext")
+		}
+
+		browserCtx, err := bp.take(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if browserCtx == nil {
+			// There's room for another context
+			if browserCtx, err = bp.createContext(); err != nil {
+				<-bp.slots
+				return nil, err
+			}
+		} else if reason := bp.retireReason(browserCtx); reason != "" {
+			bp.discard(browserCtx, reason)
+			continue
+		}
+
+		bp.mu.Lock()
+		bp.stats.InUse++
 		bp.mu.Unlock()
-		return nil, &ScrapingError{Op: "GetContext", Err: "browser pool is closed"}
+		browserContexts.With("in_use").Inc()
+		return browserCtx, nil
 	}
-	bp.mu.Unlock()
+}
 
+// take waits for an idle context, or for a free slot, in which case it returns nil
+func (bp *BrowserPool) take(ctx context.Context) (*pooledContext, error) {
+	// Reuse idle contexts before opening new ones
 	select {
-	case browserCtx := <-bp.contexts:
+	case browserCtx := <-bp.idle:
+		browserContexts.With("idle").Dec()
 		return browserCtx, nil
 	default:
-		// No context available, create a new one
-		return bp.createContext()
+	}
+
+	select {
+	case browserCtx := <-bp.idle:
+		browserContexts.With("idle").Dec()
+		return browserCtx, nil
+	case bp.slots <- struct{}{}:
+		return nil, nil
+	case <-ctx.Done():
+		return nil, &ScrapingError{Op: "GetContext", Kind: kindForError(ctx.Err()), Err: ctx.Err()}
 	}
 }
 
-// ReturnContext returns a context to the pool for reuse
+// ReturnContext returns a context to the pool for reuse, closing it instead if it's been used
+// enough or has stopped working
 func (bp *BrowserPool) ReturnContext(browserCtx playwright.BrowserContext) {
+	browserContexts.With("in_use").Dec()
 	bp.mu.Lock()
-	defer bp.mu.Unlock()
+	bp.stats.InUse--
+	bp.mu.Unlock()
 
-	if bp.closed {
+	pooled, ok := browserCtx.(*pooledContext)
+	if !ok {
 		browserCtx.Close()
 		return
 	}
+	bp.release(pooled)
+}
+
+// release puts a context back in the idle pool, or closes it if it shouldn't be reused
+func (bp *BrowserPool) release(browserCtx *pooledContext) {
+	bp.mu.Lock()
+	reason := bp.retireReasonLocked(browserCtx)
+	if reason == "" {
+		// Never blocks, the idle pool has room for every open context
+		bp.idle <- browserCtx
+		browserContexts.With("idle").Inc()
+		bp.mu.Unlock()
+		return
+	}
+	bp.mu.Unlock()
 
-	select {
-	case bp.contexts <- browserCtx:
-		// Successfully returned to pool
+	bp.discard(browserCtx, reason)
+}
+
+// retireReason returns why a context shouldn't be used again, or "" if it can be
+func (bp *BrowserPool) retireReason(browserCtx *pooledContext) string {
+	bp.mu.Lock()
+	defer bp.mu.Unlock()
+	return bp.retireReasonLocked(browserCtx)
+}
+
+func (bp *BrowserPool) retireReasonLocked(browserCtx *pooledContext) string {
+	switch {
+	case bp.closed:
+		return closePool
+	case browserCtx.broken, browserCtx.browser != bp.browser, !browserCtx.browser.IsConnected():
+		return closeUnhealthy
+	case bp.config.ContextMaxPages > 0 && browserCtx.pages >= bp.config.ContextMaxPages:
+		return closeRecycled
 	default:
-		// Pool is full, close the context
-		browserCtx.Close()
+		return ""
 	}
 }
 
-// Close closes all browser contexts and the pool
-func (bp *BrowserPool) Close() {
+// discard closes a context, freeing its slot
+func (bp *BrowserPool) discard(browserCtx *pooledContext, reason string) {
+	browserCtx.Close()
+	<-bp.slots
+
+	bp.mu.Lock()
+	switch reason {
+	case closeRecycled:
+		bp.stats.Recycled++
+	case closeUnhealthy:
+		bp.stats.Unhealthy++
+	}
+	bp.mu.Unlock()
+	browserContextsClosed.With(reason).Inc()
+}
+
+// Stats returns a snapshot of the pool
+func (bp *BrowserPool) Stats() BrowserPoolStats {
 	bp.mu.Lock()
 	defer bp.mu.Unlock()
 
+	stats := bp.stats
+	stats.Open = len(bp.slots)
+	stats.Idle = len(bp.idle)
+	return stats
+}
+
+// Close closes all browser contexts, the pool and its browser
+func (bp *BrowserPool) Close() {
+	bp.mu.Lock()
 	if bp.closed {
+		bp.mu.Unlock()
 		return
 	}
 	bp.closed = true
+	browser := bp.browser
+	bp.mu.Unlock()
 
-	close(bp.contexts)
-	for browserCtx := range bp.contexts {
-		browserCtx.Close()
+	close(bp.stopHealth)
+	<-bp.healthDone
+
+	// Contexts still in use are closed as they're returned
+drain:
+	for {
+		select {
+		case browserCtx := <-bp.idle:
+			browserContexts.With("idle").Dec()
+			bp.discard(browserCtx, closePool)
+		default:
+			break drain
+		}
+	}
+	if browser != nil {
+		browser.Close()
+	}
+
+	stats := bp.Stats()
+	bp.log.WithFields(logrus.Fields{
+		"contexts_created":   stats.Created,
+		"contexts_recycled":  stats.Recycled,
+		"contexts_unhealthy": stats.Unhealthy,
+		"pages":              stats.Pages,
+		"relaunches":         stats.Relaunches,
+	}).Info("Browser pool closed")
+}
+
+func (bp *BrowserPool) isClosed() bool {
+	bp.mu.Lock()
+	defer bp.mu.Unlock()
+	return bp.closed
+}
+
+func errPoolClosed(op string) error {
+	return &ScrapingError{Op: op, Kind: KindCanceled, Err: errors.New("browser pool is closed")}
+}
+
+// currentBrowser returns the pool's browser, relaunching it first if it has disconnected
+func (bp *BrowserPool) currentBrowser() (playwright.Browser, error) {
+	bp.launchMu.Lock()
+	defer bp.launchMu.Unlock()
+
+	bp.mu.Lock()
+	previous, closed := bp.browser, bp.closed
+	bp.mu.Unlock()
+	if closed {
+		return nil, errPoolClosed("LaunchBrowser")
+	}
+	if previous != nil && previous.IsConnected() {
+		return previous, nil
+	}
+
+	if previous != nil {
+		bp.log.Warn("Browser disconnected, relaunching it")
+		previous.Close()
+	}
+	browser, err := bp.launch()
+	if err != nil {
+		return nil, &ScrapingError{Op: "LaunchBrowser", Kind: KindBrowser, Err: err}
 	}
+
+	bp.mu.Lock()
+	defer bp.mu.Unlock()
+	if bp.closed {
+		browser.Close()
+		return nil, errPoolClosed("LaunchBrowser")
+	}
+	bp.browser = browser
+	if previous != nil {
+		bp.stats.Relaunches++
+		browserRelaunches.With().Inc()
+	}
+	return browser, nil
 }
 
 // createContext creates a new browser context with standard configuration
-func (bp *BrowserPool) createContext() (playwright.BrowserContext, error) {
-	return bp.browser.NewContext(playwright.BrowserNewContextOptions{
+func (bp *BrowserPool) createContext() (*pooledContext, error) {
+	browser, err := bp.currentBrowser()
+	if err != nil {
+		return nil, err
+	}
+
+	browserCtx, err := browser.NewContext(playwright.BrowserNewContextOptions{
 		UserAgent: playwright.String(getRandomUserAgentInternal()),
 		Viewport:  &playwright.Size{Width: 1920, Height: 1080},
 	})
+	if err != nil {
+		return nil, &ScrapingError{Op: "CreateContext", Kind: KindBrowser, Err: err}
+	}
+
+	bp.mu.Lock()
+	bp.stats.Created++
+	bp.mu.Unlock()
+	return &pooledContext{BrowserContext: browserCtx, browser: browser}, nil
+}
+
+// checkHealthEvery checks the pool's health on an interval until it's closed
+func (bp *BrowserPool) checkHealthEvery(interval time.Duration) {
+	defer close(bp.healthDone)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-bp.stopHealth:
+			return
+		case <-ticker.C:
+			bp.CheckHealth()
+		}
+	}
+}
+
+// CheckHealth relaunches the browser if it has disconnected, and replaces idle contexts that
+// can't run a page. Contexts in use are checked when they're returned.
+func (bp *BrowserPool) CheckHealth() {
+	if _, err := bp.currentBrowser(); err != nil {
+		bp.log.WithError(err).Warn("Browser health check failed")
+		return
+	}
+
+	for range len(bp.idle) {
+		var browserCtx *pooledContext
+		select {
+		case browserCtx = <-bp.idle:
+			browserContexts.With("idle").Dec()
+		default:
+			return
+		}
+
+		if err := bp.probeContext(browserCtx); err != nil {
+			bp.log.WithError(err).Warn("Browser context failed its health check, replacing it")
+			browserCtx.broken = true
+		}
+		bp.release(browserCtx)
+	}
+
+	stats := bp.Stats()
+	bp.log.WithFields(logrus.Fields{
+		"open":   stats.Open,
+		"idle":   stats.Idle,
+		"in_use": stats.InUse,
+	}).Debug("Browser pool checked")
+}
+
+// probeContext runs a trivial script in a new page. A hung renderer fails the probe after
+// PageLoadTimeout, or as soon as the pool closes, and is left to finish once its context is closed.
+func (bp *BrowserPool) probeContext(browserCtx playwright.BrowserContext) error {
+	done := make(chan error, 1)
+	go func() {
+		page, err := browserCtx.NewPage()
+		if err != nil {
+			done <- err
+			return
+		}
+		defer page.Close()
+
+		_, err = page.Evaluate("1 + 1")
+		done <- err
+	}()
+
+	timer := time.NewTimer(bp.config.PageLoadTimeout)
+	defer timer.Stop()
+	select {
+	case err := <-done:
+		return err
+	case <-timer.C:
+		return &ScrapingError{Op: "ProbeContext", Kind: KindTimeout, Err: fmt.Errorf("no response after %s", bp.config.PageLoadTimeout)}
+	case <-bp.stopHealth:
+		return &ScrapingError{Op: "ProbeContext", Kind: KindCanceled, Err: errors.New("browser pool closed")}
+	}
 }
 
 // WithContext executes a function with a browser context from the pool
@@ -114,9 +435,17 @@ func (bp *BrowserPool) WithContext(ctx context.Context, fn func(playwright.Brows
 // WithPage executes a function with a page created from a pooled context
 func (bp *BrowserPool) WithPage(ctx context.Context, fn func(playwright.Page) error) error {
 	return bp.WithContext(ctx, func(browserCtx playwright.BrowserContext) error {
-		page, err := browserCtx.NewPage()
+		// GetContext only hands out pooled contexts
+		pooled := browserCtx.(*pooledContext)
+		pooled.pages++
+		bp.mu.Lock()
+		bp.stats.Pages++
+		bp.mu.Unlock()
+
+		page, err := pooled.NewPage()
 		if err != nil {
-			return &ScrapingError{Op: "CreatePage", Err: err.Error()}
+			pooled.broken = true
+			return &ScrapingError{Op: "CreatePage", Kind: KindBrowser, Err: err}
 		}
 		defer page.Close()
 
@@ -127,24 +456,14 @@ func (bp *BrowserPool) WithPage(ctx context.Context, fn func(playwright.Page) er
 			logger.New(ctx).WithError(err).Warn("Failed to add stealth script")
 		}
 
-		return fn(page)
+		err = fn(page)
+		if ErrorKindOf(err) == KindBrowser {
+			pooled.broken = true
+		}
+		return err
 	})
 }
 
-// ScrapingError represents an error that occurred during scraping
-type ScrapingError struct {
-	Op  string // Operation that failed
-	URL string // URL being scraped (optional)
-	Err string // Error message
-}
-
-func (e *ScrapingError) Error() string {
-	if e.URL != "" {
-		return e.Op + " failed for " + e.URL + ": " + e.Err
-	}
-	return e.Op + " failed: " + e.Err
-}
-
 // getRandomUserAgentInternal returns a random user agent string (internal version)
 func getRandomUserAgentInternal() string {
 	userAgents := []string{
@@ -0,0 +1,251 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/playwright-community/playwright-go"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeBrowser stands in for Chromium, implementing only what the pool uses
+type fakeBrowser struct {
+	playwright.Browser
+
+	mu        sync.Mutex
+	connected bool
+}
+
+func (b *fakeBrowser) IsConnected() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.connected
+}
+
+func (b *fakeBrowser) NewContext(...playwright.BrowserNewContextOptions) (playwright.BrowserContext, error) {
+	if !b.IsConnected() {
+		return nil, errors.New("browser has been closed")
+	}
+	return &fakeBrowserContext{browser: b}, nil
+}
+
+func (b *fakeBrowser) Close(...playwright.BrowserCloseOptions) error {
+	b.crash()
+	return nil
+}
+
+func (b *fakeBrowser) crash() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.connected = false
+}
+
+type fakeBrowserContext struct {
+	playwright.BrowserContext
+	browser *fakeBrowser
+	hung    chan struct{} // When set, pages open but scripts block until it's closed
+}
+
+func (c *fakeBrowserContext) NewPage() (playwright.Page, error) {
+	if !c.browser.IsConnected() {
+		return nil, errors.New("target closed")
+	}
+	return &fakePage{context: c}, nil
+}
+
+func (c *fakeBrowserContext) Close(...playwright.BrowserContextCloseOptions) error {
+	return nil
+}
+
+type fakePage struct {
+	playwright.Page
+	context *fakeBrowserContext
+}
+
+func (p *fakePage) AddInitScript(playwright.Script) error { return nil }
+
+func (p *fakePage) Close(...playwright.PageCloseOptions) error { return nil }
+
+func (p *fakePage) Evaluate(string, ...any) (any, error) {
+	if p.context.hung != nil {
+		<-p.context.hung
+		return nil, playwright.ErrTargetClosed
+	}
+	return 2, nil
+}
+
+// newTestPool creates a pool of fake browsers, returning it and the browsers it has launched
+func newTestPool(t *testing.T, size, maxPages int) (*BrowserPool, *[]*fakeBrowser) {
+	t.Helper()
+
+	config := DefaultConfig()
+	config.BrowserPoolSize = size
+	config.ContextMaxPages = maxPages
+	config.BrowserHealthInterval = 0
+
+	var launched []*fakeBrowser
+	pool, err := NewBrowserPool(context.Background(), func() (playwright.Browser, error) {
+		browser := &fakeBrowser{connected: true}
+		launched = append(launched, browser)
+		return browser, nil
+	}, config)
+	require.NoError(t, err)
+	t.Cleanup(pool.Close)
+	return pool, &launched
+}
+
+func usePage(pool *BrowserPool) error {
+	return pool.WithPage(context.Background(), func(page playwright.Page) error {
+		_, err := page.Evaluate("1 + 1")
+		return err
+	})
+}
+
+func TestBrowserPoolCapsSize(t *testing.T) {
+	pool, _ := newTestPool(t, 2, 0)
+
+	first, err := pool.GetContext(context.Background())
+	require.NoError(t, err)
+	_, err = pool.GetContext(context.Background())
+	require.NoError(t, err)
+
+	// A full pool waits rather than creating another context
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err = pool.GetContext(ctx)
+	require.Equal(t, KindTimeout, ErrorKindOf(err))
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		pool.ReturnContext(first)
+	}()
+	third, err := pool.GetContext(context.Background())
+	require.NoError(t, err)
+	require.Same(t, first, third)
+
+	stats := pool.Stats()
+	require.Equal(t, 2, stats.Size)
+	require.Equal(t, 2, stats.Open)
+	require.Equal(t, 2, stats.InUse)
+	require.Equal(t, 2, stats.Created)
+}
+
+func TestBrowserPoolRecyclesContexts(t *testing.T) {
+	pool, _ := newTestPool(t, 1, 2)
+
+	for range 5 {
+		require.NoError(t, usePage(pool))
+	}
+
+	stats := pool.Stats()
+	require.Equal(t, 5, stats.Pages)
+	require.Equal(t, 2, stats.Recycled)
+	require.Equal(t, 3, stats.Created)
+	require.Equal(t, 1, stats.Open)
+}
+
+func TestBrowserPoolRelaunchesBrowser(t *testing.T) {
+	pool, launched := newTestPool(t, 2, 0)
+	require.NoError(t, usePage(pool))
+
+	(*launched)[0].crash()
+
+	// The idle contexts from the crashed browser are replaced with one from a new browser
+	require.NoError(t, usePage(pool))
+	require.Len(t, *launched, 2)
+
+	stats := pool.Stats()
+	require.Equal(t, 1, stats.Relaunches)
+	require.Equal(t, 2, stats.Unhealthy)
+	require.Equal(t, 1, stats.Open)
+
+	// The health check relaunches the browser without waiting for a page to need it
+	(*launched)[1].crash()
+	pool.CheckHealth()
+	require.Len(t, *launched, 3)
+
+	stats = pool.Stats()
+	require.Equal(t, 2, stats.Relaunches)
+	require.Equal(t, 3, stats.Unhealthy)
+	require.Zero(t, stats.Open)
+}
+
+// hang makes the pooled context's pages block running scripts until the test ends
+func hang(t *testing.T, browserCtx playwright.BrowserContext) {
+	hung := make(chan struct{})
+	t.Cleanup(func() { close(hung) })
+	browserCtx.(*pooledContext).BrowserContext.(*fakeBrowserContext).hung = hung
+}
+
+func TestBrowserPoolHealthCheck(t *testing.T) {
+	pool, _ := newTestPool(t, 2, 0)
+	pool.config.PageLoadTimeout = 20 * time.Millisecond
+	require.Equal(t, 2, pool.Stats().Idle)
+
+	browserCtx, err := pool.GetContext(context.Background())
+	require.NoError(t, err)
+	hang(t, browserCtx)
+	pool.ReturnContext(browserCtx)
+
+	// A hung renderer fails the check rather than holding it up
+	start := time.Now()
+	pool.CheckHealth()
+	require.Less(t, time.Since(start), time.Second)
+
+	stats := pool.Stats()
+	require.Equal(t, 1, stats.Unhealthy)
+	require.Equal(t, 1, stats.Idle)
+	require.NoError(t, usePage(pool))
+}
+
+func TestBrowserPoolCloseDuringHealthCheck(t *testing.T) {
+	pool, _ := newTestPool(t, 1, 0)
+	pool.config.PageLoadTimeout = time.Hour
+
+	browserCtx, err := pool.GetContext(context.Background())
+	require.NoError(t, err)
+	hang(t, browserCtx)
+	pool.ReturnContext(browserCtx)
+
+	checked := make(chan struct{})
+	go func() {
+		pool.CheckHealth()
+		close(checked)
+	}()
+	require.Eventually(t, func() bool { return pool.Stats().Idle == 0 }, time.Second, time.Millisecond)
+
+	// Closing the pool gives up on the probe instead of waiting out the timeout
+	closed := make(chan struct{})
+	go func() {
+		pool.Close()
+		close(closed)
+	}()
+	for _, done := range []chan struct{}{checked, closed} {
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("health check held up closing the pool")
+		}
+	}
+	require.Zero(t, pool.Stats().Open)
+}
+
+func TestBrowserPoolClose(t *testing.T) {
+	pool, launched := newTestPool(t, 2, 0)
+	browserCtx, err := pool.GetContext(context.Background())
+	require.NoError(t, err)
+
+	pool.Close()
+	require.False(t, (*launched)[0].IsConnected())
+	require.Equal(t, 1, pool.Stats().Open)
+
+	// Contexts in use when the pool closed are closed when they're returned
+	pool.ReturnContext(browserCtx)
+	require.Zero(t, pool.Stats().Open)
+
+	_, err = pool.GetContext(context.Background())
+	require.Equal(t, KindCanceled, ErrorKindOf(err))
+}
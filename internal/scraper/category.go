@@ -0,0 +1,158 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/sirupsen/logrus"
+
+	"github.com/salt-today/salttoday2/internal/logger"
+	"github.com/salt-today/salttoday2/internal/store"
+)
+
+// CategoryCrawl bounds how far back a category's listing pages are walked
+type CategoryCrawl struct {
+	MaxPages int       // Listing pages to fetch per category, 0 for unlimited
+	Since    time.Time // Stop once a page only lists articles published before this, zero for no limit
+	Until    time.Time // Skip articles published after this, zero for no limit
+	FromID   int       // Stop once a page lists articles with lower IDs than this, 0 for no limit
+	ToID     int       // Skip articles with higher IDs than this, 0 for no limit
+}
+
+// categoryCrawl returns the crawl bounds for regular category crawling
+func (c *ScrapingConfig) categoryCrawl() CategoryCrawl {
+	crawl := CategoryCrawl{MaxPages: c.CategoryMaxPages}
+	if c.CategoryMaxAge > 0 {
+		crawl.Since = time.Now().Add(-c.CategoryMaxAge)
+	}
+	return crawl
+}
+
+// discoverFromCategories walks every category's listing pages on a site
+func (s *Scraper) discoverFromCategories(ctx context.Context, siteURL string, crawl CategoryCrawl) (map[int]*store.Article, error) {
+	articles := make(map[int]*store.Article)
+
+	var lastErr error
+	for _, category := range articleCategories {
+		found, err := s.crawlCategory(ctx, siteURL, category, crawl, nil)
+		if err != nil {
+			lastErr = err
+		}
+		for id, article := range found {
+			if _, exists := articles[id]; !exists {
+				articles[id] = article
+			}
+		}
+	}
+
+	if len(articles) == 0 {
+		return articles, lastErr
+	}
+	return articles, nil
+}
+
+// crawlCategory follows a category's listing pages from newest to oldest until the crawl's
+// bounds are reached, calling onPage (if set) after each page with its number and articles
+func (s *Scraper) crawlCategory(ctx context.Context, siteURL, category string, crawl CategoryCrawl, onPage func(page int, articles map[int]*store.Article) error) (map[int]*store.Article, error) {
+	logEntry := logger.New(ctx).WithFields(logrus.Fields{"site": siteURL, "category": category})
+	articles := make(map[int]*store.Article)
+	listed := make(map[int]bool)
+
+	pageURL := siteURL + strings.TrimSuffix(category, "/")
+	for page := 1; pageURL != "" && (crawl.MaxPages == 0 || page <= crawl.MaxPages); page++ {
+		doc, err := s.fetchDocument(ctx, pageURL, s.config.NavigationTimeout)
+		if err != nil {
+			if ErrorKindOf(err) == KindNotFound && page == 1 {
+				// Not every site has every category
+				logEntry.Debug("Category not found on site")
+				return articles, nil
+			}
+			return articles, err
+		}
+
+		parser := s.parserFor(siteURL)
+		found := parser.ParseArticles(ctx, doc, siteURL)
+		published := parser.ParseListingTimes(ctx, doc)
+
+		pageArticles := make(map[int]*store.Article)
+		reachedSince := len(found) == 0
+		repeated := true // A page of articles we've already listed means pagination was ignored
+		for id, article := range found {
+			if !listed[id] {
+				listed[id] = true
+				repeated = false
+			}
+			article.DiscoverySource = store.DiscoverySourceCategory
+			// Article IDs are sequential, so listings are in descending ID order too
+			if crawl.FromID > 0 && id < crawl.FromID {
+				reachedSince = true
+				continue
+			}
+			if crawl.ToID > 0 && id > crawl.ToID {
+				continue
+			}
+			if publishedAt, ok := published[id]; ok {
+				if !crawl.Since.IsZero() && publishedAt.Before(crawl.Since) {
+					reachedSince = true
+					continue
+				}
+				if !crawl.Until.IsZero() && publishedAt.After(crawl.Until) {
+					continue
+				}
+				// Old articles found in listings shouldn't look freshly discovered to the scheduler
+				if publishedAt.Before(article.DiscoveryTime) {
+					article.DiscoveryTime = publishedAt
+				}
+			}
+			if _, seen := articles[id]; !seen {
+				pageArticles[id] = article
+				articles[id] = article
+			}
+		}
+
+		logEntry.WithFields(logrus.Fields{
+			"page":     page,
+			"listed":   len(found),
+			"articles": len(pageArticles),
+		}).Debug("Crawled category page")
+
+		if onPage != nil {
+			if err := onPage(page, pageArticles); err != nil {
+				return articles, err
+			}
+		}
+
+		if reachedSince || repeated {
+			break
+		}
+		pageURL = nextListingPage(doc, pageURL, page)
+	}
+
+	return articles, nil
+}
+
+// nextListingPage returns the URL of the next listing page, preferring rel="next" links
+func nextListingPage(doc *goquery.Document, pageURL string, page int) string {
+	current, err := url.Parse(pageURL)
+	if err != nil {
+		return ""
+	}
+
+	if href, ok := doc.Find(`a[rel="next"], link[rel="next"]`).First().Attr("href"); ok {
+		next, err := current.Parse(href)
+		if err != nil || next.String() == pageURL {
+			return ""
+		}
+		return next.String()
+	}
+
+	// Village Media listings otherwise page with a "page" query parameter
+	query := current.Query()
+	query.Set("page", fmt.Sprint(page+1))
+	current.RawQuery = query.Encode()
+	return current.String()
+}
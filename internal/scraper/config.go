@@ -1,12 +1,22 @@
 package scraper
 
-import "time"
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/salt-today/salttoday2/internal"
+)
 
 // ScrapingConfig holds all configuration for the scraper
 type ScrapingConfig struct {
+	// Sites to scrape by name, every enabled site when empty
+	Sites []string
+
 	// Concurrency settings
-	MaxArticleWorkers int
-	MaxCommentWorkers int
+	MaxArticleWorkers  int
+	MaxCommentWorkers  int
+	CommentWriteBuffer int // Scraped articles that can wait to be stored before workers block
 
 	// Timeout settings
 	NavigationTimeout time.Duration
@@ -16,21 +26,89 @@ type ScrapingConfig struct {
 	MaxRetries      int
 	RetryDelay      time.Duration
 	BackoffMultiple float64
+	MaxRetryDelay   time.Duration
+
+	// Politeness settings, enforced separately for every host
+	HostRequestsPerSecond float64       // Token bucket refill rate, 0 for unlimited
+	HostBurst             int           // Token bucket size
+	MaxConcurrentPerHost  int           // In-flight requests per host, 0 for unlimited
+	CrawlDelay            time.Duration // Minimum gap between requests to a host
+
+	// robots.txt settings
+	RespectRobotsTxt bool
+	RobotsUserAgent  string // Product token matched against User-agent groups
+	RobotsCacheTTL   time.Duration
 
 	// Browser settings
-	HeadlessMode  bool
-	DisableImages bool
+	HeadlessMode          bool
+	DisableImages         bool
+	BrowserPoolSize       int           // Most browser contexts open at once, 0 for one per worker
+	ContextMaxPages       int           // Pages a browser context serves before it's replaced, 0 to reuse it forever
+	BrowserHealthInterval time.Duration // How often the browser and idle contexts are checked, 0 to never check
+
+	// Fetch settings
+	DefaultFetchMode FetchMode
+	SiteFetchModes   map[string]FetchMode // Keyed by site name, overrides DefaultFetchMode
+
+	// Archive settings
+	ArchiveMode ArchiveMode
+	ArchiveDir  string
+	ArchiveRun  string // Run to replay, or the name to record under (defaults to the start time)
 
 	// Limits
-	MaxCommentPages int
-	MaxReplyChain   int
+	MaxCommentPages int // Pages fetched for an article's top level comments, and for each comment's replies
+	MaxReplyChain   int // How deeply replies to replies are followed
+
+	// Discovery settings, in addition to homepage links
+	DiscoverSitemaps bool
+	SitemapPaths     []string
+	MaxSitemapFiles  int           // Sitemaps fetched per site, including those listed in indexes
+	SitemapMaxAge    time.Duration // Skip sitemaps and entries last modified before this
+	DiscoverFeeds    bool
+	FeedPaths        []string
+
+	// Category crawling walks each category's listing pages back in time
+	CrawlCategories  bool
+	CategoryMaxPages int           // Listing pages per category, 0 for unlimited
+	CategoryMaxAge   time.Duration // Stop at articles published before this, 0 for no limit
+
+	// Daemon settings
+	HomepagePollInterval time.Duration
+	ScheduleMaxAge       time.Duration // Articles discovered longer ago than this are no longer scraped
+	MaxScrapeBatch       int           // Due articles scraped together, 0 for no limit
+	ShutdownGracePeriod  time.Duration // How long in-flight work may run after a shutdown signal
+
+	// Adaptive scheduling starts from an interval based on article age, then adjusts it after every scrape
+	MinScrapeInterval time.Duration
+	MaxScrapeInterval time.Duration
+	BusyCommentGrowth int           // New comments seen by one scrape that halve the interval
+	RetireAfterStable time.Duration // Stop scraping articles without new comments or votes for this long, 0 to never retire
+
+	// Parser drift detection stops storing a site's comments once too many of them fail to parse
+	DriftMinComments     int           // Comments a site needs in a run before its parse quality is judged
+	DriftMaxFallbackRate float64       // Share of comments missing an ID, user, text or time that quarantines the site
+	AlertCooldown        time.Duration // Minimum time between repeats of the same alert for a site
+
+	// Coordination between scrapers sharing a database, through leases on the articles being scraped
+	InstanceID string        // Names this scraper in the lease table, generated when empty
+	LeaseTTL   time.Duration // How long a lease lasts without a heartbeat, 0 to scrape without leases
+
+	// Commenter profile pages, refreshed slowly in the background by the daemon
+	ScrapeProfiles         bool
+	ProfileRefreshInterval time.Duration // How old a scraped profile gets before it's scraped again
+	ProfilePollInterval    time.Duration // How often the daemon looks for profiles due a scrape
+	MaxProfilesPerPoll     int
+
+	// Dry runs read from storage as usual, but write what they would have stored as JSON lines instead
+	DryRunOutput string // Directory for the JSONL files, "-" for stdout, empty to store normally
 }
 
 // DefaultConfig returns sensible defaults for scraping
 func DefaultConfig() *ScrapingConfig {
 	return &ScrapingConfig{
-		MaxArticleWorkers: 5,
-		MaxCommentWorkers: 8,
+		MaxArticleWorkers:  5,
+		MaxCommentWorkers:  8,
+		CommentWriteBuffer: 16,
 
 		NavigationTimeout: 10 * time.Second,
 		PageLoadTimeout:   8 * time.Second,
@@ -38,13 +116,143 @@ func DefaultConfig() *ScrapingConfig {
 		MaxRetries:      3,
 		RetryDelay:      1 * time.Second,
 		BackoffMultiple: 2.0,
+		MaxRetryDelay:   30 * time.Second,
+
+		HostRequestsPerSecond: 2,
+		HostBurst:             4,
+		MaxConcurrentPerHost:  4,
+		CrawlDelay:            250 * time.Millisecond,
+
+		RespectRobotsTxt: true,
+		RobotsUserAgent:  "salttoday",
+		RobotsCacheTTL:   24 * time.Hour,
+
+		HeadlessMode:          true,
+		DisableImages:         true,
+		ContextMaxPages:       50,
+		BrowserHealthInterval: time.Minute,
 
-		HeadlessMode:  true,
-		DisableImages: true,
+		DefaultFetchMode: FetchModeHTTP, // Village Media pages render server side, sites that need a browser set fetch_mode
+		SiteFetchModes:   map[string]FetchMode{},
+
+		ArchiveMode: ArchiveModeOff,
+		ArchiveDir:  "scrape-archive",
 
 		MaxCommentPages: 10,
 		MaxReplyChain:   20,
+
+		DiscoverSitemaps: true,
+		SitemapPaths:     []string{"/sitemap.xml"},
+		MaxSitemapFiles:  10,
+		SitemapMaxAge:    7 * 24 * time.Hour,
+		DiscoverFeeds:    true,
+		FeedPaths:        []string{"/rss"},
+
+		CrawlCategories:  false,
+		CategoryMaxPages: 3,
+		CategoryMaxAge:   7 * 24 * time.Hour,
+
+		HomepagePollInterval: 10 * time.Minute,
+		ScheduleMaxAge:       7 * 24 * time.Hour,
+		MaxScrapeBatch:       50,
+		ShutdownGracePeriod:  30 * time.Second,
+
+		MinScrapeInterval: 5 * time.Minute,
+		MaxScrapeInterval: 12 * time.Hour,
+		BusyCommentGrowth: 5,
+		RetireAfterStable: 48 * time.Hour,
+
+		DriftMinComments:     20,
+		DriftMaxFallbackRate: 0.2,
+		AlertCooldown:        6 * time.Hour,
+
+		LeaseTTL: 5 * time.Minute,
+
+		ProfileRefreshInterval: 30 * 24 * time.Hour,
+		ProfilePollInterval:    time.Hour,
+		MaxProfilesPerPoll:     100,
+	}
+}
+
+// SitesToScrape maps the name of each site selected by Sites to its URL
+func (c *ScrapingConfig) SitesToScrape() map[string]string {
+	if len(c.Sites) == 0 {
+		return internal.SitesMap
+	}
+	sites := make(map[string]string, len(c.Sites))
+	for _, name := range c.Sites {
+		if url, ok := internal.SitesMap[name]; ok {
+			sites[name] = url
+		}
+	}
+	return sites
+}
+
+// scrapesURL reports whether a page belongs to one of the sites selected by Sites
+func (c *ScrapingConfig) scrapesURL(pageURL string) bool {
+	return len(c.Sites) == 0 || slices.Contains(c.Sites, siteNameForURL(pageURL))
+}
+
+// checkSites makes sure every site in Sites is enabled in the site registry
+func (c *ScrapingConfig) checkSites() error {
+	for _, name := range c.Sites {
+		if _, ok := internal.SitesMap[name]; !ok {
+			return fmt.Errorf("site %q isn't enabled in the site registry, enabled sites are %v", name, internal.SitesMapKeys)
+		}
+	}
+	return nil
+}
+
+// RetryPolicy returns the retry policy described by the retry settings
+func (c *ScrapingConfig) RetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxRetries:      c.MaxRetries,
+		InitialDelay:    c.RetryDelay,
+		BackoffMultiple: c.BackoffMultiple,
+		MaxDelay:        c.MaxRetryDelay,
+	}
+}
+
+// FetchModeFor returns the fetch mode for a site, preferring SiteFetchModes over the site registry
+func (c *ScrapingConfig) FetchModeFor(siteName string) FetchMode {
+	if mode, ok := c.SiteFetchModes[siteName]; ok {
+		return mode
+	}
+	if site, ok := internal.SiteByName(siteName); ok && site.FetchMode != "" {
+		return FetchMode(site.FetchMode)
+	}
+	return c.defaultFetchMode()
+}
+
+func (c *ScrapingConfig) defaultFetchMode() FetchMode {
+	if c.DefaultFetchMode == "" {
+		return FetchModeHTTP
+	}
+	return c.DefaultFetchMode
+}
+
+// NeedsBrowser reports whether any site is configured to fetch with Playwright
+func (c *ScrapingConfig) NeedsBrowser() bool {
+	if c.ArchiveMode == ArchiveModeReplay {
+		return false // Every page comes from the archive
+	}
+	if c.defaultFetchMode() == FetchModePlaywright {
+		return true
+	}
+	for _, site := range internal.EnabledSites() {
+		if c.FetchModeFor(site.Name) == FetchModePlaywright {
+			return true
+		}
+	}
+	return false
+}
+
+// browserPoolSize returns how many browser contexts may be open at once
+func (c *ScrapingConfig) browserPoolSize() int {
+	if c.BrowserPoolSize > 0 {
+		return c.BrowserPoolSize
 	}
+	return c.MaxArticleWorkers + c.MaxCommentWorkers
 }
 
 // BrowserArgs returns optimized browser launch arguments
@@ -0,0 +1,273 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/salt-today/salttoday2/internal/logger"
+	"github.com/salt-today/salttoday2/internal/store"
+)
+
+// daemonRetryDelay is how long an article waits after its comments failed to store
+const daemonRetryDelay = time.Minute
+
+// daemonIdleWait bounds how long the dispatcher sleeps when nothing is scheduled
+const daemonIdleWait = time.Minute
+
+// RunDaemon scrapes continuously until ctx is canceled. Homepages are polled every
+// HomepagePollInterval, and each article's comments are scraped whenever the schedule says
+// they're due. With ScrapeProfiles, commenter profiles are refreshed in the background.
+// Once ctx is canceled no new work starts, and in-flight work gets ShutdownGracePeriod to finish.
+func (s *Scraper) RunDaemon(ctx context.Context) error {
+	logEntry := logger.New(ctx).WithField("operation", "daemon")
+
+	storage, err := s.requireStorage()
+	if err != nil {
+		return err
+	}
+
+	// Work outlives ctx, so a shutdown doesn't cut off a scrape halfway through storing it
+	workCtx, cancelWork := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancelWork()
+
+	schedule := NewSchedule()
+	if err := s.loadSchedule(workCtx, storage, schedule); err != nil {
+		return err
+	}
+	logEntry.WithFields(logrus.Fields{
+		"scheduled":     schedule.Len(),
+		"poll_interval": s.config.HomepagePollInterval,
+	}).Info("Daemon started")
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		s.pollHomepages(ctx, workCtx, storage, schedule)
+	}()
+	go func() {
+		defer wg.Done()
+		s.dispatchDue(ctx, workCtx, storage, schedule)
+	}()
+	if s.config.ScrapeProfiles {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.pollProfiles(ctx, workCtx, storage)
+		}()
+	}
+
+	<-ctx.Done()
+	logEntry.Info("Shutting down daemon, waiting for in-flight work")
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(s.config.ShutdownGracePeriod):
+		logEntry.Warn("In-flight work didn't finish in time, canceling it")
+		cancelWork()
+		<-done
+	}
+
+	logEntry.Info("Daemon stopped")
+	return nil
+}
+
+// loadSchedule schedules every stored article that is still young enough to scrape
+func (s *Scraper) loadSchedule(ctx context.Context, storage store.Storage, schedule *Schedule) error {
+	now := time.Now()
+	articles, err := storage.GetRecentlyDiscoveredArticles(ctx, now.Add(-s.config.ScheduleMaxAge))
+	var noResults *store.NoQueryResultsError
+	if err != nil && !errors.As(err, &noResults) {
+		return &ScrapingError{Op: "GetRecentArticles", Kind: KindStorage, Err: err}
+	}
+
+	for _, article := range articles {
+		if s.config.scrapesURL(article.Url) && !s.retireArticle(article, now) {
+			schedule.Schedule(article, nextScrapeTime(article, now))
+		}
+	}
+	return nil
+}
+
+// pollHomepages discovers new articles every HomepagePollInterval until ctx is canceled
+func (s *Scraper) pollHomepages(ctx, workCtx context.Context, storage store.Storage, schedule *Schedule) {
+	ticker := time.NewTicker(s.config.HomepagePollInterval)
+	defer ticker.Stop()
+
+	for {
+		s.pollHomepagesOnce(workCtx, storage, schedule)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// pollHomepagesOnce stores newly discovered articles and schedules any that aren't already.
+// Articles from quarantined sites are stored but not scheduled, as scrapeDue drops them too.
+func (s *Scraper) pollHomepagesOnce(ctx context.Context, storage store.Storage, schedule *Schedule) {
+	logEntry := logger.New(ctx).WithField("operation", "poll_homepages")
+
+	discovered, err := s.scrapeArticlesConcurrently(ctx, s.config.SitesToScrape())
+	if err != nil {
+		logEntry.WithError(err).Error("Failed to poll homepages")
+		return
+	}
+	if len(discovered) == 0 {
+		return
+	}
+
+	articles := make([]*store.Article, 0, len(discovered))
+	ids := make([]int, 0, len(discovered))
+	for id, article := range discovered {
+		articles = append(articles, article)
+		ids = append(ids, id)
+	}
+	if err := storage.AddArticles(ctx, articles...); err != nil {
+		logEntry.WithError(err).Error("Failed to store discovered articles")
+		return
+	}
+
+	// Schedule from what's stored, since articles seen before keep their original discovery time
+	stored, err := storage.GetArticles(ctx, ids...)
+	if err != nil {
+		logEntry.WithError(err).Error("Failed to load discovered articles")
+		return
+	}
+
+	now := time.Now()
+	added := 0
+	for _, article := range stored {
+		if schedule.Contains(article.ID) || s.retireArticle(article, now) || s.isQuarantined(metricSite(article.Url)) {
+			continue
+		}
+		schedule.Schedule(article, nextScrapeTime(article, now))
+		added++
+	}
+
+	logEntry.WithFields(logrus.Fields{
+		"discovered": len(discovered),
+		"scheduled":  added,
+	}).Info("Polled homepages")
+}
+
+// retireArticle reports whether an article should no longer be scheduled, because it's older than
+// ScheduleMaxAge, its comments have stopped changing, or it was removed or had comments closed
+func (s *Scraper) retireArticle(article *store.Article, now time.Time) bool {
+	return article.State != "" || now.Sub(article.DiscoveryTime) > s.config.ScheduleMaxAge || s.config.isRetired(article, now)
+}
+
+// dispatchDue scrapes articles as they come due until ctx is canceled
+func (s *Scraper) dispatchDue(ctx, workCtx context.Context, storage store.Storage, schedule *Schedule) {
+	for {
+		if ctx.Err() != nil {
+			return
+		}
+
+		if due := schedule.PopDue(time.Now(), s.config.MaxScrapeBatch); len(due) > 0 {
+			s.scrapeDue(workCtx, storage, schedule, due)
+			continue
+		}
+
+		wait := daemonIdleWait
+		if next, ok := schedule.NextDue(); ok {
+			wait = min(time.Until(next), daemonIdleWait)
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-schedule.Wake():
+			timer.Stop()
+		case <-timer.C:
+		}
+	}
+}
+
+// scrapeDue scrapes and stores the comments of due articles, then schedules their next scrape.
+// Articles that couldn't be stored are retried shortly.
+func (s *Scraper) scrapeDue(ctx context.Context, storage store.Storage, schedule *Schedule, due []*store.Article) {
+	logEntry := logger.New(ctx).WithField("operation", "scrape_due")
+
+	claimed, release, err := s.claimArticles(ctx, storage, due)
+	if err != nil {
+		logEntry.WithError(err).Error("Failed to claim due articles, retrying shortly")
+		for _, article := range due {
+			schedule.Schedule(article, time.Now().Add(daemonRetryDelay))
+		}
+		return
+	}
+	defer release()
+
+	// Articles another scraper holds come round again once its lease would be up, and those
+	// it scraped since they were scheduled wait for their next turn
+	now := time.Now()
+	claimedByID := make(map[int]*store.Article, len(claimed))
+	for _, article := range claimed {
+		claimedByID[article.ID] = article
+	}
+	var toScrape []*store.Article
+	for _, article := range due {
+		fresh, ok := claimedByID[article.ID]
+		switch {
+		case !ok:
+			schedule.Schedule(article, now.Add(s.config.LeaseTTL))
+		case s.retireArticle(fresh, now):
+		case nextScrapeTime(fresh, now).After(now):
+			schedule.Schedule(fresh, nextScrapeTime(fresh, now))
+		default:
+			toScrape = append(toScrape, fresh)
+		}
+	}
+	if len(toScrape) == 0 {
+		return
+	}
+
+	summary, err := s.scrapeAndStoreCommentsConcurrently(ctx, storage, toScrape)
+
+	now = time.Now()
+	retired := 0
+	for _, article := range toScrape {
+		if summary.leaseLost[article.ID] {
+			schedule.Schedule(article, now.Add(s.config.LeaseTTL))
+			continue
+		}
+		if _, failed := summary.failed[article.ID]; failed {
+			schedule.Schedule(article, now.Add(daemonRetryDelay))
+			continue
+		}
+		if summary.quarantined[article.ID] {
+			continue // Picked up again once the daemon restarts with a fixed parser
+		}
+
+		if s.retireArticle(article, now) {
+			retired++
+			continue
+		}
+		schedule.Schedule(article, nextScrapeTime(article, now))
+	}
+
+	if err != nil {
+		logEntry.WithError(err).WithField("retrying", len(summary.failed)).Error("Failed to store some due articles, retrying shortly")
+	}
+	logEntry.WithFields(logrus.Fields{
+		"articles":  len(toScrape),
+		"comments":  summary.comments,
+		"retired":   retired,
+		"scheduled": schedule.Len(),
+	}).Info("Scraped due articles")
+}
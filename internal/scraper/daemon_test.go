@@ -0,0 +1,67 @@
+package scraper
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/salt-today/salttoday2/internal/store"
+)
+
+func TestRunDaemon(t *testing.T) {
+	server := newFixtureServer(t, "villagemedia")
+	withFixtureSite(t, server.URL)
+
+	// Already stored and due, and too old to schedule at all
+	storage := newFakeStorage(
+		&store.Article{ID: 1000002, Url: server.URL + "/city-police-beat/man-charged-after-downtown-crash-1000002", DiscoveryTime: time.Now().Add(-time.Hour)},
+		&store.Article{ID: 999, Url: server.URL + "/local-news/old-999", DiscoveryTime: time.Now().Add(-30 * 24 * time.Hour)},
+	)
+	s := newTestScraper(t, storage)
+	s.config.HomepagePollInterval = time.Hour
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- s.RunDaemon(ctx) }()
+
+	// Every homepage article gets its comments scraped without waiting for the next poll
+	require.Eventually(t, func() bool {
+		storage.mu.Lock()
+		defer storage.mu.Unlock()
+		for _, id := range []int{1000001, 1000002, 1000003} {
+			if _, ok := storage.scrapedAt[id]; !ok {
+				return false
+			}
+		}
+		return true
+	}, 5*time.Second, 10*time.Millisecond)
+
+	cancel()
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("daemon didn't shut down")
+	}
+
+	require.Equal(t, 1, server.requestCount("/"))
+	require.NotContains(t, storage.scrapedAt, 999)
+	require.NotEmpty(t, storage.commentIDs())
+}
+
+func TestPollHomepagesSkipsQuarantinedSites(t *testing.T) {
+	server := newFixtureServer(t, "villagemedia")
+	siteName := withFixtureSite(t, server.URL)
+	storage := newFakeStorage()
+	s := newTestScraper(t, storage)
+
+	// Articles dropped from the schedule after the site was quarantined aren't brought back
+	s.quarantineSite(context.Background(), siteName, "parser drifted")
+	schedule := NewSchedule()
+	s.pollHomepagesOnce(context.Background(), storage, schedule)
+
+	require.Zero(t, schedule.Len())
+	require.Contains(t, storage.articles, 1000001) // Still stored, to be scraped once the parser is fixed
+}
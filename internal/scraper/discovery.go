@@ -0,0 +1,258 @@
+package scraper
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/salt-today/salttoday2/internal/logger"
+	"github.com/salt-today/salttoday2/internal/store"
+)
+
+// sitemapDoc decodes either a sitemap index or a URL set, including Google News extensions
+type sitemapDoc struct {
+	Sitemaps []struct {
+		Loc     string `xml:"loc"`
+		LastMod string `xml:"lastmod"`
+	} `xml:"sitemap"`
+	URLs []struct {
+		Loc     string `xml:"loc"`
+		LastMod string `xml:"lastmod"`
+		News    struct {
+			Title           string `xml:"title"`
+			PublicationDate string `xml:"publication_date"`
+		} `xml:"news"`
+	} `xml:"url"`
+}
+
+// feedDoc decodes either an RSS 2.0 or an Atom feed
+type feedDoc struct {
+	Items []struct {
+		Title   string `xml:"title"`
+		Link    string `xml:"link"`
+		PubDate string `xml:"pubDate"`
+	} `xml:"channel>item"`
+	Entries []struct {
+		Title string `xml:"title"`
+		Links []struct {
+			Href string `xml:"href,attr"`
+			Rel  string `xml:"rel,attr"`
+		} `xml:"link"`
+		Published string `xml:"published"`
+		Updated   string `xml:"updated"`
+	} `xml:"entry"`
+}
+
+// discoverArticlesFromSite finds a site's articles on its homepage, sitemaps, feeds and categories,
+// keeping the first source that found each article
+func (s *Scraper) discoverArticlesFromSite(ctx context.Context, siteURL string) (map[int]*store.Article, error) {
+	logEntry := logger.New(ctx).WithField("site", siteURL)
+
+	articles, err := s.scrapeArticlesFromSite(ctx, siteURL)
+	if err != nil {
+		logEntry.WithError(err).Warn("Failed to scrape homepage, relying on sitemaps and feeds")
+		countError(err)
+		articles = make(map[int]*store.Article)
+	}
+	errs := []error{err}
+
+	merge := func(source string, found map[int]*store.Article, sourceErr error) {
+		if sourceErr != nil {
+			logEntry.WithError(sourceErr).WithField("source", source).Warn("Article discovery source failed")
+			errs = append(errs, sourceErr)
+		}
+		added := 0
+		for id, article := range found {
+			if _, exists := articles[id]; !exists {
+				articles[id] = article
+				added++
+			}
+		}
+		logEntry.WithFields(logrus.Fields{
+			"source": source,
+			"found":  len(found),
+			"added":  added,
+		}).Debug("Merged discovered articles")
+	}
+
+	if s.config.DiscoverSitemaps {
+		found, err := s.discoverFromSitemaps(ctx, siteURL)
+		merge(store.DiscoverySourceSitemap, found, err)
+	}
+	if s.config.DiscoverFeeds {
+		found, err := s.discoverFromFeeds(ctx, siteURL)
+		merge(store.DiscoverySourceFeed, found, err)
+	}
+	if s.config.CrawlCategories {
+		found, err := s.discoverFromCategories(ctx, siteURL, s.config.categoryCrawl())
+		merge(store.DiscoverySourceCategory, found, err)
+	}
+
+	// Only fail the site when no source worked at all
+	if len(articles) == 0 {
+		if err := errors.Join(errs...); err != nil {
+			return nil, err
+		}
+	}
+	return articles, nil
+}
+
+// discoverFromSitemaps walks the site's sitemaps, following sitemap indexes up to MaxSitemapFiles
+func (s *Scraper) discoverFromSitemaps(ctx context.Context, siteURL string) (map[int]*store.Article, error) {
+	articles := make(map[int]*store.Article)
+	cutoff := time.Now().Add(-s.config.SitemapMaxAge)
+
+	queue := make([]string, 0, len(s.config.SitemapPaths))
+	for _, path := range s.config.SitemapPaths {
+		queue = append(queue, siteURL+path)
+	}
+	seen := make(map[string]bool)
+
+	var errs []error
+	for len(queue) > 0 && len(seen) < s.config.MaxSitemapFiles {
+		sitemapURL := queue[0]
+		queue = queue[1:]
+		if seen[sitemapURL] {
+			continue
+		}
+		seen[sitemapURL] = true
+
+		var doc sitemapDoc
+		if err := s.fetchXML(ctx, sitemapURL, &doc); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		for _, sitemap := range doc.Sitemaps {
+			// Indexes list every historical sitemap, only follow recently modified ones
+			if modified, ok := parseFeedTime(sitemap.LastMod); ok && modified.Before(cutoff) {
+				continue
+			}
+			queue = append(queue, strings.TrimSpace(sitemap.Loc))
+		}
+
+		for _, entry := range doc.URLs {
+			published, ok := parseFeedTime(entry.News.PublicationDate)
+			if !ok {
+				published, ok = parseFeedTime(entry.LastMod)
+			}
+			if ok && published.Before(cutoff) {
+				continue
+			}
+			if article := s.articleFromLink(ctx, siteURL, entry.Loc, entry.News.Title, published, store.DiscoverySourceSitemap); article != nil {
+				articles[article.ID] = article
+			}
+		}
+	}
+
+	if len(articles) == 0 {
+		return articles, errors.Join(errs...)
+	}
+	return articles, nil
+}
+
+// discoverFromFeeds reads the site's RSS and Atom feeds
+func (s *Scraper) discoverFromFeeds(ctx context.Context, siteURL string) (map[int]*store.Article, error) {
+	articles := make(map[int]*store.Article)
+
+	var errs []error
+	for _, path := range s.config.FeedPaths {
+		var doc feedDoc
+		if err := s.fetchXML(ctx, siteURL+path, &doc); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		for _, item := range doc.Items {
+			published, _ := parseFeedTime(item.PubDate)
+			if article := s.articleFromLink(ctx, siteURL, item.Link, item.Title, published, store.DiscoverySourceFeed); article != nil {
+				articles[article.ID] = article
+			}
+		}
+		for _, entry := range doc.Entries {
+			published, ok := parseFeedTime(entry.Published)
+			if !ok {
+				published, _ = parseFeedTime(entry.Updated)
+			}
+			for _, link := range entry.Links {
+				if link.Rel != "" && link.Rel != "alternate" {
+					continue
+				}
+				if article := s.articleFromLink(ctx, siteURL, link.Href, entry.Title, published, store.DiscoverySourceFeed); article != nil {
+					articles[article.ID] = article
+				}
+			}
+		}
+	}
+
+	if len(articles) == 0 {
+		return articles, errors.Join(errs...)
+	}
+	return articles, nil
+}
+
+// fetchXML fetches a sitemap or feed over plain HTTP, since a browser would render XML as a viewer page
+func (s *Scraper) fetchXML(ctx context.Context, xmlURL string, v any) error {
+	content, err := s.fetchers[FetchModeHTTP].Fetch(ctx, xmlURL, s.config.NavigationTimeout)
+	if err != nil {
+		return err
+	}
+
+	if err := xml.Unmarshal([]byte(content), v); err != nil {
+		return &ScrapingError{Op: "ParseXML", URL: xmlURL, Kind: KindParse, Err: err}
+	}
+	return nil
+}
+
+// articleFromLink builds an article from an absolute link if it is an article on the site. Its
+// publication time, when the source lists one, is used as the discovery time if it's earlier.
+func (s *Scraper) articleFromLink(ctx context.Context, siteURL, link, title string, published time.Time, source string) *store.Article {
+	site, err := url.Parse(siteURL)
+	if err != nil {
+		return nil
+	}
+	u, err := url.Parse(strings.TrimSpace(link))
+	if err != nil || (u.Host != "" && u.Host != site.Host) || !isArticleUrl(u.Path) {
+		return nil
+	}
+
+	articleID := getArticleId(ctx, u.Path)
+	if articleID == 0 {
+		return nil
+	}
+
+	// Old articles found in sitemaps and feeds shouldn't look freshly discovered to the scheduler
+	now := time.Now()
+	discoveryTime := now
+	if !published.IsZero() && published.Before(now) {
+		discoveryTime = published
+	}
+
+	return &store.Article{
+		ID:              articleID,
+		Title:           strings.TrimSpace(title),
+		Url:             siteURL + u.Path,
+		DiscoveryTime:   discoveryTime,
+		LastScrapeTime:  now,
+		DiscoverySource: source,
+	}
+}
+
+// parseFeedTime parses the date formats used by sitemaps, RSS and Atom
+func parseFeedTime(value string) (time.Time, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, false
+	}
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04-07:00", time.DateOnly, time.RFC1123Z, time.RFC1123} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
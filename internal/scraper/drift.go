@@ -0,0 +1,228 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/salt-today/salttoday2/internal/logger"
+)
+
+// Comment fields that fall back to a default when they can't be parsed
+const (
+	fieldCommentID = "comment_id"
+	fieldUserID    = "user_id"
+	fieldText      = "text"
+	fieldTime      = "time"
+	fieldReplies   = "replies"
+	fieldLikes     = "likes"
+	fieldDislikes  = "dislikes"
+)
+
+// driftFields are the fallbacks that, in bulk, mean a site's markup has changed under the parser
+var driftFields = []string{fieldCommentID, fieldUserID, fieldText, fieldTime}
+
+// driftMinUnanimous is the fewest comments, every one missing the same field, that quarantine a
+// site whose run ended before it had DriftMinComments to judge
+const driftMinUnanimous = 5
+
+// parseTally counts the comments parsed and the fields that fell back to defaults
+type parseTally struct {
+	mu        sync.Mutex
+	comments  int
+	fallbacks map[string]int
+}
+
+func newParseTally() *parseTally {
+	return &parseTally{fallbacks: make(map[string]int)}
+}
+
+type parseTallyKey struct{}
+
+// withParseTally has comments parsed under the returned context counted in tally
+func withParseTally(ctx context.Context, tally *parseTally) context.Context {
+	return context.WithValue(ctx, parseTallyKey{}, tally)
+}
+
+// noteComment counts a parsed comment
+func noteComment(ctx context.Context) {
+	if tally, ok := ctx.Value(parseTallyKey{}).(*parseTally); ok {
+		tally.mu.Lock()
+		tally.comments++
+		tally.mu.Unlock()
+	}
+}
+
+// noteFallback counts a comment field that couldn't be parsed
+func noteFallback(ctx context.Context, field string) {
+	parseFallbacks.With(field).Inc()
+	if tally, ok := ctx.Value(parseTallyKey{}).(*parseTally); ok {
+		tally.mu.Lock()
+		tally.fallbacks[field]++
+		tally.mu.Unlock()
+	}
+}
+
+// add merges another tally into this one
+func (t *parseTally) add(other *parseTally) {
+	other.mu.Lock()
+	defer other.mu.Unlock()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.comments += other.comments
+	for field, count := range other.fallbacks {
+		t.fallbacks[field] += count
+	}
+}
+
+// drifted reports the first drift field whose share of comments is above maxRate. Tallies of
+// fewer than minComments comments are too small to judge.
+func (t *parseTally) drifted(minComments int, maxRate float64) (string, float64, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.comments == 0 || t.comments < minComments {
+		return "", 0, false
+	}
+	for _, field := range driftFields {
+		if rate := float64(t.fallbacks[field]) / float64(t.comments); rate > maxRate {
+			return field, rate, true
+		}
+	}
+	return "", 0, false
+}
+
+// count returns how many comments have been tallied
+func (t *parseTally) count() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.comments
+}
+
+// unanimous reports the first drift field missing from every one of at least minComments comments
+func (t *parseTally) unanimous(minComments int) (string, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.comments == 0 || t.comments < minComments {
+		return "", false
+	}
+	for _, field := range driftFields {
+		if t.fallbacks[field] >= t.comments {
+			return field, true
+		}
+	}
+	return "", false
+}
+
+// driftCheck follows parse quality per site over one run, holding back each site's results until
+// it has enough comments to be judged, so a broken parser's first articles aren't stored either
+type driftCheck struct {
+	tallies map[string]*parseTally
+	held    map[string][]*articleComments
+}
+
+func newDriftCheck() *driftCheck {
+	return &driftCheck{tallies: make(map[string]*parseTally), held: make(map[string][]*articleComments)}
+}
+
+// checkDrift adds an article's tally to its site's, quarantining the site if it has drifted. It
+// returns the results that can now be stored, and those rejected because the site is quarantined.
+// Both are empty while the site's results are held for too few comments to judge.
+func (s *Scraper) checkDrift(ctx context.Context, drift *driftCheck, site string, result *articleComments) (ready, rejected []*articleComments) {
+	if reason, ok := s.quarantineReason(site); ok {
+		s.alert(ctx, Alert{Site: site, Kind: AlertParserDrift, Message: reason, Time: time.Now()})
+		return nil, []*articleComments{result}
+	}
+
+	siteTally, ok := drift.tallies[site]
+	if !ok {
+		siteTally = newParseTally()
+		drift.tallies[site] = siteTally
+	}
+	siteTally.add(result.tally)
+
+	drift.held[site] = append(drift.held[site], result)
+	if siteTally.count() < s.config.DriftMinComments {
+		return nil, nil
+	}
+	held := drift.held[site]
+	delete(drift.held, site)
+
+	field, rate, drifted := siteTally.drifted(s.config.DriftMinComments, s.config.DriftMaxFallbackRate)
+	if !drifted {
+		return held, nil
+	}
+
+	s.quarantineSite(ctx, site, fmt.Sprintf("%.0f%% of %d comments had no parseable %s, writes are quarantined until the parser is fixed",
+		rate*100, siteTally.count(), field))
+	return nil, held
+}
+
+// flushDrift releases the results still held when a run ends. Their sites had too few comments to
+// judge by rate, so they're only quarantined when every comment is missing the same field.
+func (s *Scraper) flushDrift(ctx context.Context, drift *driftCheck) (ready, rejected []*articleComments) {
+	for site, held := range drift.held {
+		if field, broken := drift.tallies[site].unanimous(driftMinUnanimous); broken {
+			s.quarantineSite(ctx, site, fmt.Sprintf("all %d comments had no parseable %s, writes are quarantined until the parser is fixed",
+				drift.tallies[site].count(), field))
+			rejected = append(rejected, held...)
+			continue
+		}
+		ready = append(ready, held...)
+	}
+	clear(drift.held)
+	return ready, rejected
+}
+
+// loadQuarantines picks up the sites quarantined by earlier runs, which stay quarantined until
+// they're cleared with "salttoday quarantine -clear"
+func (s *Scraper) loadQuarantines(ctx context.Context) error {
+	sites, err := s.storage.GetQuarantinedSites(ctx)
+	if err != nil {
+		return &ScrapingError{Op: "GetQuarantinedSites", Kind: KindStorage, Err: err}
+	}
+
+	s.alertMu.Lock()
+	defer s.alertMu.Unlock()
+	for site, reason := range sites {
+		logger.New(ctx).WithField("site", site).WithField("reason", reason).Warn("Site is still quarantined, its comments won't be stored")
+		s.quarantined[site] = reason
+		quarantinedSites.With(site).Set(1)
+	}
+	return nil
+}
+
+// quarantineSite stops storing comments from a site, for this run and, with storage, the runs
+// after it until the quarantine is cleared
+func (s *Scraper) quarantineSite(ctx context.Context, site, reason string) {
+	s.alertMu.Lock()
+	s.quarantined[site] = reason
+	s.alertMu.Unlock()
+
+	quarantinedSites.With(site).Set(1)
+	if s.storage != nil {
+		if err := s.storage.QuarantineSite(ctx, site, reason, time.Now()); err != nil {
+			logger.New(ctx).WithError(err).WithField("site", site).Error("Failed to store quarantine, it only lasts for this run")
+		}
+	}
+	s.alert(ctx, Alert{Site: site, Kind: AlertParserDrift, Message: reason, Time: time.Now()})
+}
+
+// quarantineReason returns why a site's comments are no longer being stored, if they aren't
+func (s *Scraper) quarantineReason(site string) (string, bool) {
+	s.alertMu.Lock()
+	defer s.alertMu.Unlock()
+	reason, ok := s.quarantined[site]
+	return reason, ok
+}
+
+// isQuarantined reports whether a site's comments are no longer being stored
+func (s *Scraper) isQuarantined(site string) bool {
+	s.alertMu.Lock()
+	defer s.alertMu.Unlock()
+	_, ok := s.quarantined[site]
+	return ok
+}
@@ -0,0 +1,172 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/salt-today/salttoday2/internal/store"
+)
+
+// recordingNotifier keeps every alert it's sent
+type recordingNotifier struct {
+	mu     sync.Mutex
+	alerts []Alert
+}
+
+func (n *recordingNotifier) Notify(_ context.Context, alert Alert) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.alerts = append(n.alerts, alert)
+	return nil
+}
+
+func TestParseTallyDrifted(t *testing.T) {
+	tally := newParseTally()
+	ctx := withParseTally(context.Background(), tally)
+	for range 10 {
+		noteComment(ctx)
+	}
+	noteFallback(ctx, fieldLikes) // Not a drift field
+	noteFallback(ctx, fieldLikes)
+	noteFallback(ctx, fieldLikes)
+	noteFallback(ctx, fieldTime)
+
+	_, _, drifted := tally.drifted(5, 0.2)
+	require.False(t, drifted)
+
+	noteFallback(ctx, fieldTime)
+	noteFallback(ctx, fieldTime)
+	field, rate, drifted := tally.drifted(5, 0.2)
+	require.True(t, drifted)
+	require.Equal(t, fieldTime, field)
+	require.InDelta(t, 0.3, rate, 0.001)
+
+	// Too few comments to judge
+	_, _, drifted = tally.drifted(20, 0.2)
+	require.False(t, drifted)
+}
+
+func TestParseTallyUnanimous(t *testing.T) {
+	tally := newParseTally()
+	ctx := withParseTally(context.Background(), tally)
+	for range 5 {
+		noteComment(ctx)
+		noteFallback(ctx, fieldUserID)
+	}
+	noteFallback(ctx, fieldTime)
+
+	field, broken := tally.unanimous(5)
+	require.True(t, broken)
+	require.Equal(t, fieldUserID, field)
+
+	// Too few comments to judge
+	_, broken = tally.unanimous(6)
+	require.False(t, broken)
+
+	noteComment(ctx)
+	_, broken = tally.unanimous(5)
+	require.False(t, broken)
+}
+
+func TestScrapeQuarantinesDriftedSite(t *testing.T) {
+	server := newFixtureServer(t, "villagemedia")
+	withFixtureSite(t, server.URL)
+	article := func(id int) *store.Article {
+		return &store.Article{
+			ID:            id,
+			Url:           fmt.Sprintf("%s/local-news/story-%d", server.URL, id),
+			DiscoveryTime: time.Now().Add(-time.Hour),
+		}
+	}
+
+	// The recorded comments use markup the parser doesn't know, so none have a user
+	drifted := article(1000030)
+	storage := newFakeStorage(drifted)
+	s := newTestScraper(t, storage)
+	s.config.DriftMinComments = 3
+	notifier := &recordingNotifier{}
+	s.SetNotifier(notifier)
+
+	summary, err := s.scrapeAndStoreCommentsConcurrently(context.Background(), storage, []*store.Article{drifted})
+	require.NoError(t, err)
+	require.True(t, summary.quarantined[drifted.ID])
+	require.Empty(t, storage.comments)
+	require.NotContains(t, storage.scrapedAt, drifted.ID)
+
+	require.Len(t, notifier.alerts, 1)
+	require.Equal(t, "FixtureToday", notifier.alerts[0].Site)
+	require.Equal(t, AlertParserDrift, notifier.alerts[0].Kind)
+
+	// The quarantine outlasts the run, even for articles that parse cleanly
+	healthy := article(1000001)
+	summary, err = s.scrapeAndStoreCommentsConcurrently(context.Background(), storage, []*store.Article{healthy})
+	require.NoError(t, err)
+	require.True(t, summary.quarantined[healthy.ID])
+	require.Empty(t, storage.comments)
+	require.Len(t, notifier.alerts, 1)
+}
+
+func TestScrapeHoldsCommentsUntilDriftIsJudged(t *testing.T) {
+	server := newFixtureServer(t, "villagemedia")
+	withFixtureSite(t, server.URL)
+	healthy := &store.Article{ID: 1000001, Url: server.URL + "/local-news/story-1000001", DiscoveryTime: time.Now().Add(-time.Hour)}
+	drifted := &store.Article{ID: 1000030, Url: server.URL + "/local-news/story-1000030", DiscoveryTime: time.Now().Add(-time.Hour)}
+
+	storage := newFakeStorage(healthy, drifted)
+	s := newTestScraper(t, storage)
+	s.config.MaxCommentWorkers = 1
+	s.config.DriftMinComments = 12 // More than the healthy article has, so the site is judged once both are in
+
+	// The healthy article is scraped first, but isn't stored before the drifted one sinks the site
+	summary, err := s.scrapeAndStoreCommentsConcurrently(context.Background(), storage, []*store.Article{healthy, drifted})
+	require.NoError(t, err)
+	require.True(t, summary.quarantined[healthy.ID])
+	require.True(t, summary.quarantined[drifted.ID])
+	require.Empty(t, storage.comments)
+}
+
+func TestQuarantineOutlastsScraper(t *testing.T) {
+	server := newFixtureServer(t, "villagemedia")
+	site := withFixtureSite(t, server.URL)
+	healthy := &store.Article{ID: 1000001, Url: server.URL + "/local-news/story-1000001", DiscoveryTime: time.Now().Add(-time.Hour)}
+
+	storage := newFakeStorage(healthy)
+	newTestScraper(t, storage).quarantineSite(context.Background(), site, "parser drifted")
+	require.Equal(t, map[string]string{site: "parser drifted"}, storage.quarantined)
+
+	// The next run picks the quarantine back up
+	s := newTestScraper(t, storage)
+	notifier := &recordingNotifier{}
+	s.SetNotifier(notifier)
+	summary, err := s.scrapeAndStoreCommentsConcurrently(context.Background(), storage, []*store.Article{healthy})
+	require.NoError(t, err)
+	require.True(t, summary.quarantined[healthy.ID])
+	require.Empty(t, storage.comments)
+	require.Len(t, notifier.alerts, 1)
+
+	// Until it's cleared
+	require.NoError(t, storage.ClearSiteQuarantine(context.Background(), site))
+	s = newTestScraper(t, storage)
+	summary, err = s.scrapeAndStoreCommentsConcurrently(context.Background(), storage, []*store.Article{healthy})
+	require.NoError(t, err)
+	require.False(t, summary.quarantined[healthy.ID])
+	require.NotEmpty(t, storage.comments)
+}
+
+func TestAlertCooldown(t *testing.T) {
+	s := newTestScraper(t, newFakeStorage())
+	notifier := &recordingNotifier{}
+	s.SetNotifier(notifier)
+
+	now := time.Now()
+	s.alert(context.Background(), Alert{Site: "SooToday", Kind: AlertNoArticles, Time: now})
+	s.alert(context.Background(), Alert{Site: "SooToday", Kind: AlertNoArticles, Time: now.Add(time.Hour)})
+	s.alert(context.Background(), Alert{Site: "BayToday", Kind: AlertNoArticles, Time: now.Add(time.Hour)})
+	s.alert(context.Background(), Alert{Site: "SooToday", Kind: AlertNoArticles, Time: now.Add(s.config.AlertCooldown)})
+	require.Len(t, notifier.alerts, 3)
+}
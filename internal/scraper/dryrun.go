@@ -0,0 +1,391 @@
+package scraper
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/salt-today/salttoday2/internal/logger"
+	"github.com/salt-today/salttoday2/internal/store"
+)
+
+// Dry run record types, each written to <type>s.jsonl when the output is a directory
+const (
+	dryRunArticle = "article"
+	dryRunComment = "comment"
+	dryRunUser    = "user"
+	dryRunDiff    = "diff"
+)
+
+// Changes a dry run found between the scraped comments and the stored ones
+const (
+	DiffNewComment = "new"
+	DiffVotes      = "votes"
+	DiffDeleted    = "deleted" // Stored, but no longer on the article, so it would be marked deleted
+
+	// So many comments are missing that storing wouldn't mark any of them deleted
+	DiffMassDeletionSkipped = "mass_deletion_skipped"
+)
+
+// DryRunDiff counts how a dry run's comments differ from what's stored
+type DryRunDiff struct {
+	NewComments int
+	VoteChanges int
+	Deletions   int
+
+	MassDeletionsSkipped int // Articles missing too many comments for any to be marked deleted
+}
+
+// dryRunOutput writes what a dry run would have stored as JSON lines, either all to one stream or to
+// a file per record type, and tallies how it differs from storage
+type dryRunOutput struct {
+	mu       sync.Mutex
+	stream   *json.Encoder            // Every record, when writing to a stream
+	encoders map[string]*json.Encoder // Keyed by record type, when writing to a directory
+	files    []*os.File
+	diff     DryRunDiff
+}
+
+// newDryRunOutput writes to stdout when target is "-", otherwise to files in the target directory
+func newDryRunOutput(target string) (*dryRunOutput, error) {
+	if target == "-" {
+		return newDryRunStream(os.Stdout), nil
+	}
+
+	if err := os.MkdirAll(target, 0o755); err != nil {
+		return nil, &ScrapingError{Op: "OpenDryRunOutput", Err: err}
+	}
+	o := &dryRunOutput{encoders: make(map[string]*json.Encoder)}
+	for _, kind := range []string{dryRunArticle, dryRunComment, dryRunUser, dryRunDiff} {
+		f, err := os.Create(filepath.Join(target, kind+"s.jsonl"))
+		if err != nil {
+			o.Close()
+			return nil, &ScrapingError{Op: "OpenDryRunOutput", Err: err}
+		}
+		o.files = append(o.files, f)
+		o.encoders[kind] = json.NewEncoder(f)
+	}
+	return o, nil
+}
+
+// newDryRunStream writes every record to w, tagged with its type
+func newDryRunStream(w io.Writer) *dryRunOutput {
+	return &dryRunOutput{stream: json.NewEncoder(w)}
+}
+
+// dryRunRecord is a line of output
+type dryRunRecord struct {
+	Type string `json:"type"`
+	Data any    `json:"data"`
+}
+
+func (o *dryRunOutput) write(kind string, data any) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.stream != nil {
+		return o.stream.Encode(dryRunRecord{Type: kind, Data: data})
+	}
+	return o.encoders[kind].Encode(data)
+}
+
+// Diff returns the changes counted so far
+func (o *dryRunOutput) Diff() DryRunDiff {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.diff
+}
+
+// Close closes the output files, if there are any
+func (o *dryRunOutput) Close() error {
+	var err error
+	for _, f := range o.files {
+		err = errors.Join(err, f.Close())
+	}
+	return err
+}
+
+type articleRecord struct {
+	ID              int       `json:"id"`
+	URL             string    `json:"url"`
+	Title           string    `json:"title"`
+	SiteName        string    `json:"site_name"`
+	DiscoveryTime   time.Time `json:"discovery_time"`
+	DiscoverySource string    `json:"discovery_source,omitempty"`
+}
+
+type commentRecord struct {
+	ID        int       `json:"id"`
+	ArticleID int       `json:"article_id"`
+	UserID    int       `json:"user_id"`
+	Time      time.Time `json:"time"`
+	Text      string    `json:"text"`
+	Likes     int32     `json:"likes"`
+	Dislikes  int32     `json:"dislikes"`
+}
+
+type userRecord struct {
+	ID                  int        `json:"id"`
+	UserName            string     `json:"user_name,omitempty"`
+	JoinTime            *time.Time `json:"join_time,omitempty"`
+	AvatarURL           string     `json:"avatar_url,omitempty"`
+	ProfileCommentCount int        `json:"profile_comment_count,omitempty"`
+}
+
+type diffRecord struct {
+	Change      string `json:"change"`
+	CommentID   int    `json:"comment_id"`
+	ArticleID   int    `json:"article_id"`
+	Likes       int32  `json:"likes"`
+	Dislikes    int32  `json:"dislikes"`
+	OldLikes    *int32 `json:"old_likes,omitempty"`
+	OldDislikes *int32 `json:"old_dislikes,omitempty"`
+}
+
+// massDeletionRecord stands in for the deletions of an article missing too many comments
+type massDeletionRecord struct {
+	Change    string `json:"change"`
+	ArticleID int    `json:"article_id"`
+	Missing   int    `json:"missing"`
+	Stored    int    `json:"stored"` // Live comments stored for the article
+}
+
+// dryRunStorage reads from the store it wraps, but writes to a dry run's output instead.
+// Bookkeeping such as scrape times, activity, leases and run ledgers is dropped.
+type dryRunStorage struct {
+	store.Storage
+	out *dryRunOutput
+}
+
+func (d *dryRunStorage) AddArticles(_ context.Context, articles ...*store.Article) error {
+	for _, article := range articles {
+		err := d.out.write(dryRunArticle, articleRecord{
+			ID:              article.ID,
+			URL:             article.Url,
+			Title:           article.Title,
+			SiteName:        article.SiteName,
+			DiscoveryTime:   article.DiscoveryTime,
+			DiscoverySource: article.DiscoverySource,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *dryRunStorage) AddUsers(_ context.Context, users ...*store.User) error {
+	for _, user := range users {
+		if err := d.out.write(dryRunUser, userRecord{ID: user.ID, UserName: user.UserName}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *dryRunStorage) SetUserProfiles(_ context.Context, users ...*store.User) error {
+	for _, user := range users {
+		record := userRecord{
+			ID:                  user.ID,
+			UserName:            user.UserName,
+			AvatarURL:           user.AvatarURL,
+			ProfileCommentCount: user.ProfileCommentCount,
+		}
+		if !user.JoinTime.IsZero() {
+			record.JoinTime = &user.JoinTime
+		}
+		if err := d.out.write(dryRunUser, record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AddComments writes the comments, then how each article's comments differ from the stored ones,
+// comparing them the same way storing them would
+func (d *dryRunStorage) AddComments(ctx context.Context, comments []*store.Comment) error {
+	return d.addComments(ctx, comments, true)
+}
+
+// UpdateComments is AddComments for a partial scrape, where missing comments aren't deletions
+func (d *dryRunStorage) UpdateComments(ctx context.Context, comments []*store.Comment) error {
+	return d.addComments(ctx, comments, false)
+}
+
+func (d *dryRunStorage) addComments(ctx context.Context, comments []*store.Comment, markDeleted bool) error {
+	articleComments := make(map[int][]*store.Comment)
+	for _, comment := range comments {
+		err := d.out.write(dryRunComment, commentRecord{
+			ID:        comment.ID,
+			ArticleID: comment.Article.ID,
+			UserID:    comment.User.ID,
+			Time:      comment.Time,
+			Text:      comment.Text,
+			Likes:     comment.Likes,
+			Dislikes:  comment.Dislikes,
+		})
+		if err != nil {
+			return err
+		}
+		articleComments[comment.Article.ID] = append(articleComments[comment.Article.ID], comment)
+	}
+
+	var err error
+	for articleID, comments := range articleComments {
+		err = errors.Join(err, d.diffArticle(ctx, articleID, comments, markDeleted))
+	}
+	return err
+}
+
+func (d *dryRunStorage) diffArticle(ctx context.Context, articleID int, comments []*store.Comment, markDeleted bool) error {
+	stored, err := d.Storage.GetArticleComments(ctx, articleID)
+	if err != nil && !errors.Is(err, &store.NoQueryResultsError{}) {
+		return err
+	}
+
+	storedByID := make(map[int]*store.Comment, len(stored))
+	for _, comment := range stored {
+		storedByID[comment.ID] = comment
+	}
+
+	var diffs []diffRecord
+	scraped := make(map[int]bool, len(comments))
+	for _, comment := range comments {
+		scraped[comment.ID] = true
+		record := diffRecord{CommentID: comment.ID, ArticleID: articleID, Likes: comment.Likes, Dislikes: comment.Dislikes}
+
+		old, ok := storedByID[comment.ID]
+		switch {
+		case !ok:
+			record.Change = DiffNewComment
+		case old.Likes != comment.Likes || old.Dislikes != comment.Dislikes:
+			record.Change = DiffVotes
+			record.OldLikes, record.OldDislikes = &old.Likes, &old.Dislikes
+		default:
+			continue
+		}
+		diffs = append(diffs, record)
+	}
+
+	var deletions []diffRecord
+	live := 0
+	for _, comment := range stored {
+		if comment.Deleted {
+			continue
+		}
+		live++
+		if !scraped[comment.ID] {
+			deletions = append(deletions, diffRecord{
+				Change:    DiffDeleted,
+				CommentID: comment.ID,
+				ArticleID: articleID,
+				Likes:     comment.Likes,
+				Dislikes:  comment.Dislikes,
+			})
+		}
+	}
+
+	massDeletion := markDeleted && store.IsMassDeletion(len(deletions), live)
+	if massDeletion {
+		err := d.out.write(dryRunDiff, massDeletionRecord{
+			Change:    DiffMassDeletionSkipped,
+			ArticleID: articleID,
+			Missing:   len(deletions),
+			Stored:    live,
+		})
+		if err != nil {
+			return err
+		}
+	} else if markDeleted {
+		diffs = append(diffs, deletions...)
+	}
+
+	for _, record := range diffs {
+		if err := d.out.write(dryRunDiff, record); err != nil {
+			return err
+		}
+	}
+
+	d.out.mu.Lock()
+	defer d.out.mu.Unlock()
+	if massDeletion {
+		d.out.diff.MassDeletionsSkipped++
+	}
+	for _, record := range diffs {
+		switch record.Change {
+		case DiffNewComment:
+			d.out.diff.NewComments++
+		case DiffVotes:
+			d.out.diff.VoteChanges++
+		case DiffDeleted:
+			d.out.diff.Deletions++
+		}
+	}
+	return nil
+}
+
+// Every article is ours to scrape, nothing else is told about it
+func (d *dryRunStorage) ClaimArticles(_ context.Context, _ string, _ time.Time, articleIDs ...int) ([]int, error) {
+	return articleIDs, nil
+}
+
+func (d *dryRunStorage) RenewArticleLeases(_ context.Context, _ string, _ time.Time, articleIDs ...int) ([]int, error) {
+	return articleIDs, nil
+}
+
+func (d *dryRunStorage) ReleaseArticles(context.Context, string, ...int) error {
+	return nil
+}
+
+func (d *dryRunStorage) SetArticleScrapedAt(context.Context, time.Time, ...int) error {
+	return nil
+}
+
+func (d *dryRunStorage) SetUserProfileScrapedAt(context.Context, time.Time, ...int) error {
+	return nil
+}
+
+func (d *dryRunStorage) SetArticleActivity(context.Context, ...*store.Article) error {
+	return nil
+}
+
+func (d *dryRunStorage) SetArticleState(context.Context, string, ...int) error {
+	return nil
+}
+
+func (d *dryRunStorage) QuarantineSite(context.Context, string, string, time.Time) error {
+	return nil
+}
+
+func (d *dryRunStorage) AddScrapeRun(context.Context, *store.ScrapeRun) error {
+	return nil
+}
+
+func (d *dryRunStorage) UpdateScrapeRun(context.Context, *store.ScrapeRun) error {
+	return nil
+}
+
+// closeDryRun logs how the dry run differed from storage and closes its output
+func (s *Scraper) closeDryRun() error {
+	if s.dryRun == nil {
+		return nil
+	}
+	diff := s.dryRun.Diff()
+	logger.New(context.Background()).WithFields(logrus.Fields{
+		"new_comments":           diff.NewComments,
+		"vote_changes":           diff.VoteChanges,
+		"deletions":              diff.Deletions,
+		"mass_deletions_skipped": diff.MassDeletionsSkipped,
+	}).Info("Dry run complete, nothing was stored")
+
+	err := s.dryRun.Close()
+	s.dryRun = nil
+	return err
+}
@@ -0,0 +1,119 @@
+package scraper
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/salt-today/salttoday2/internal/store"
+)
+
+func TestDryRun(t *testing.T) {
+	server := newFixtureServer(t, "villagemedia")
+	article := &store.Article{
+		ID:            1000001,
+		Url:           server.URL + "/local-news/city-council-approves-new-budget-1000001",
+		DiscoveryTime: time.Now().Add(-time.Hour),
+	}
+	storage := newFakeStorage(article)
+	// One comment whose votes have changed since it was stored, and one that's gone from the article
+	storage.comments[101] = &store.Comment{ID: 101, Article: *article, Likes: 999}
+	storage.comments[999] = &store.Comment{ID: 999, Article: *article, Likes: 1}
+
+	var out bytes.Buffer
+	s := newTestScraper(t, storage)
+	s.dryRun = newDryRunStream(&out)
+	s.storage = &dryRunStorage{Storage: storage, out: s.dryRun}
+
+	require.NoError(t, s.ScrapeAndStoreComments(context.Background(), 1, true))
+
+	// Nothing is written to storage
+	require.Equal(t, []int{101, 999}, storage.commentIDs())
+	require.Len(t, storage.users, 0)
+	require.NotContains(t, storage.scrapedAt, article.ID)
+
+	require.Equal(t, DryRunDiff{NewComments: 9, VoteChanges: 1, Deletions: 1}, s.dryRun.Diff())
+
+	counts := make(map[string]int)
+	changes := make(map[int]map[string]any)
+	scanner := bufio.NewScanner(&out)
+	for scanner.Scan() {
+		var record struct {
+			Type string         `json:"type"`
+			Data map[string]any `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &record))
+		counts[record.Type]++
+		if record.Type == dryRunDiff {
+			changes[int(record.Data["comment_id"].(float64))] = record.Data
+		}
+	}
+	require.Equal(t, map[string]int{dryRunComment: 10, dryRunUser: 4, dryRunDiff: 11}, counts)
+
+	require.Equal(t, DiffVotes, changes[101]["change"])
+	require.Equal(t, float64(999), changes[101]["old_likes"])
+	require.Equal(t, DiffDeleted, changes[999]["change"])
+	require.Equal(t, DiffNewComment, changes[202]["change"])
+}
+
+func TestDryRunOutputDirectory(t *testing.T) {
+	dir := t.TempDir()
+	out, err := newDryRunOutput(dir)
+	require.NoError(t, err)
+
+	storage := &dryRunStorage{Storage: newFakeStorage(), out: out}
+	require.NoError(t, storage.AddArticles(context.Background(), &store.Article{ID: 1, Url: "https://www.example.com/a-1"}))
+	require.NoError(t, out.Close())
+
+	for _, name := range []string{"articles.jsonl", "comments.jsonl", "users.jsonl", "diffs.jsonl"} {
+		require.FileExists(t, filepath.Join(dir, name))
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "articles.jsonl"))
+	require.NoError(t, err)
+
+	var record articleRecord
+	require.NoError(t, json.Unmarshal(data, &record))
+	require.Equal(t, "https://www.example.com/a-1", record.URL)
+}
+
+func TestDryRunSkipsMassDeletion(t *testing.T) {
+	article := store.Article{ID: 1}
+	storage := newFakeStorage(&article)
+	for id := 1; id <= 6; id++ {
+		storage.comments[id] = &store.Comment{ID: id, Article: article}
+	}
+
+	var out bytes.Buffer
+	dryRun := &dryRunStorage{Storage: storage, out: newDryRunStream(&out)}
+
+	// Five of six comments missing looks like a broken scrape, so none would be marked deleted
+	require.NoError(t, dryRun.AddComments(context.Background(), []*store.Comment{{ID: 1, Article: article}}))
+	require.Equal(t, DryRunDiff{MassDeletionsSkipped: 1}, dryRun.out.Diff())
+	require.Contains(t, out.String(), `{"type":"diff","data":{"change":"mass_deletion_skipped","article_id":1,"missing":5,"stored":6}}`)
+	require.NotContains(t, out.String(), `"change":"deleted"`)
+}
+
+func TestDryRunComparesEveryStoredComment(t *testing.T) {
+	article := store.Article{ID: 1}
+	storage := newFakeStorage(&article)
+	var scraped []*store.Comment
+	for id := 1; id <= 30; id++ {
+		storage.comments[id] = &store.Comment{ID: id, Article: article, Likes: int32(100 - id)}
+		scraped = append(scraped, &store.Comment{ID: id, Article: article, Likes: int32(100 - id)})
+	}
+	// More stored than a page of GetComments holds, with a change and a deletion outside the top page
+	scraped[24].Likes++
+	scraped = scraped[:29]
+
+	var out bytes.Buffer
+	dryRun := &dryRunStorage{Storage: storage, out: newDryRunStream(&out)}
+	require.NoError(t, dryRun.AddComments(context.Background(), scraped))
+	require.Equal(t, DryRunDiff{VoteChanges: 1, Deletions: 1}, dryRun.out.Diff())
+}
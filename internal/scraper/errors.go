@@ -0,0 +1,187 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+// ErrorKind classifies a scraping failure so callers can decide whether to retry it
+type ErrorKind int
+
+const (
+	KindUnknown     ErrorKind = iota
+	KindTimeout               // Navigation or request deadline exceeded
+	KindNetwork               // Connection refused, reset or DNS failure
+	KindServer                // 5xx response
+	KindRateLimited           // 429 response
+	KindNotFound              // 404 or 410 response, or missing from the archive
+	KindClient                // Any other 4xx response
+	KindParse                 // Response could not be parsed
+	KindBrowser               // Browser or page could not be created
+	KindStorage               // Reading from or writing to storage failed
+	KindCanceled              // Context canceled or scraper shutting down
+	KindDisallowed            // Blocked by the site's robots.txt
+	KindIncomplete            // Parsed, but missing content the site says is there
+	KindTruncated             // Stopped at a page limit, what was found is returned along with the error
+)
+
+func (k ErrorKind) String() string {
+	switch k {
+	case KindTimeout:
+		return "timeout"
+	case KindNetwork:
+		return "network"
+	case KindServer:
+		return "server"
+	case KindRateLimited:
+		return "rate_limited"
+	case KindNotFound:
+		return "not_found"
+	case KindClient:
+		return "client"
+	case KindParse:
+		return "parse"
+	case KindBrowser:
+		return "browser"
+	case KindStorage:
+		return "storage"
+	case KindCanceled:
+		return "canceled"
+	case KindDisallowed:
+		return "disallowed"
+	case KindIncomplete:
+		return "incomplete"
+	case KindTruncated:
+		return "truncated"
+	default:
+		return "unknown"
+	}
+}
+
+// Retryable reports whether a failure of this kind may succeed if attempted again
+func (k ErrorKind) Retryable() bool {
+	switch k {
+	case KindTimeout, KindNetwork, KindServer, KindRateLimited, KindBrowser:
+		return true
+	default:
+		return false
+	}
+}
+
+// ScrapingError represents an error that occurred during scraping
+type ScrapingError struct {
+	Op         string        // Operation that failed
+	URL        string        // URL being scraped (optional)
+	Kind       ErrorKind     // Classification of the failure
+	StatusCode int           // HTTP status of the response (optional)
+	RetryAfter time.Duration // How long the server asked us to wait (optional)
+	Err        error         // Underlying error
+}
+
+func (e *ScrapingError) Error() string {
+	msg := e.Kind.String()
+	if e.Err != nil {
+		msg = e.Err.Error()
+	}
+	if e.URL != "" {
+		return e.Op + " failed for " + e.URL + ": " + msg
+	}
+	return e.Op + " failed: " + msg
+}
+
+func (e *ScrapingError) Unwrap() error {
+	return e.Err
+}
+
+// IsRetryable reports whether err is a scraping failure worth attempting again
+func IsRetryable(err error) bool {
+	var scrapingErr *ScrapingError
+	if errors.As(err, &scrapingErr) {
+		return scrapingErr.Kind.Retryable()
+	}
+	return false
+}
+
+// ErrorKindOf returns the kind of the first ScrapingError in err's chain
+func ErrorKindOf(err error) ErrorKind {
+	var scrapingErr *ScrapingError
+	if errors.As(err, &scrapingErr) {
+		return scrapingErr.Kind
+	}
+	return KindUnknown
+}
+
+// statusError builds the error for an unsuccessful HTTP response
+func statusError(op, url string, status int, retryAfter string) *ScrapingError {
+	return &ScrapingError{
+		Op:         op,
+		URL:        url,
+		Kind:       kindForStatus(status),
+		StatusCode: status,
+		RetryAfter: parseRetryAfter(retryAfter, time.Now()),
+		Err:        fmt.Errorf("unexpected status %d", status),
+	}
+}
+
+// parseRetryAfter reads a Retry-After header given either in seconds or as an HTTP date
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil && at.After(now) {
+		return at.Sub(now)
+	}
+	return 0
+}
+
+func kindForStatus(status int) ErrorKind {
+	switch {
+	case status == http.StatusTooManyRequests:
+		return KindRateLimited
+	case status == http.StatusNotFound || status == http.StatusGone:
+		return KindNotFound
+	case status == http.StatusRequestTimeout:
+		return KindTimeout
+	case status >= 500:
+		return KindServer
+	case status >= 400:
+		return KindClient
+	default:
+		return KindUnknown
+	}
+}
+
+// kindForError classifies a transport-level error from net/http or Playwright. Errors it doesn't
+// recognise are KindUnknown, so they aren't retried.
+func kindForError(err error) ErrorKind {
+	var netErr net.Error
+	switch {
+	case errors.Is(err, context.Canceled):
+		return KindCanceled
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, playwright.ErrTimeout):
+		return KindTimeout
+	case errors.As(err, &netErr):
+		if netErr.Timeout() {
+			return KindTimeout
+		}
+		return KindNetwork
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return KindNetwork // The connection dropped partway through the body
+	case strings.Contains(err.Error(), "net::ERR_"):
+		return KindNetwork // Chromium's network failures, as Playwright reports them
+	default:
+		return KindUnknown
+	}
+}
@@ -0,0 +1,110 @@
+package scraper
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+// FetchMode selects how pages are retrieved for a site
+type FetchMode string
+
+const (
+	// FetchModePlaywright renders pages in a pooled Chromium context
+	FetchModePlaywright FetchMode = "playwright"
+	// FetchModeHTTP issues plain HTTP requests without a browser
+	FetchModeHTTP FetchMode = "http"
+)
+
+// maxResponseSize caps how much of a response body is read into memory
+const maxResponseSize = 10 << 20
+
+// Fetcher retrieves the HTML content of a URL
+type Fetcher interface {
+	Fetch(ctx context.Context, url string, timeout time.Duration) (string, error)
+}
+
+// PlaywrightFetcher fetches pages through a browser pool
+type PlaywrightFetcher struct {
+	pool *BrowserPool
+}
+
+// NewPlaywrightFetcher creates a fetcher backed by the given browser pool
+func NewPlaywrightFetcher(pool *BrowserPool) *PlaywrightFetcher {
+	return &PlaywrightFetcher{pool: pool}
+}
+
+// Fetch navigates a pooled page to the URL and returns the rendered content
+func (f *PlaywrightFetcher) Fetch(ctx context.Context, url string, timeout time.Duration) (string, error) {
+	var content string
+
+	err := f.pool.WithPage(ctx, func(page playwright.Page) error {
+		resp, err := page.Goto(url, playwright.PageGotoOptions{
+			Timeout:   playwright.Float(float64(timeout.Milliseconds())),
+			WaitUntil: playwright.WaitUntilStateDomcontentloaded,
+		})
+		if err != nil {
+			return &ScrapingError{Op: "Navigate", URL: url, Kind: kindForError(err), Err: err}
+		}
+		if resp != nil && (resp.Status() < 200 || resp.Status() >= 400) {
+			retryAfter, _ := resp.HeaderValue("retry-after")
+			return statusError("Navigate", url, resp.Status(), retryAfter)
+		}
+
+		content, err = page.Content()
+		if err != nil {
+			return &ScrapingError{Op: "GetContent", URL: url, Kind: KindBrowser, Err: err}
+		}
+		return nil
+	})
+
+	if err != nil {
+		return "", err
+	}
+
+	return content, nil
+}
+
+// HTTPFetcher fetches pages with a plain net/http client
+type HTTPFetcher struct {
+	client *http.Client
+}
+
+// NewHTTPFetcher creates a fetcher using its own HTTP client
+func NewHTTPFetcher() *HTTPFetcher {
+	return &HTTPFetcher{client: &http.Client{}}
+}
+
+// Fetch issues a GET request for the URL and returns the response body
+func (f *HTTPFetcher) Fetch(ctx context.Context, url string, timeout time.Duration) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", &ScrapingError{Op: "CreateRequest", URL: url, Err: err}
+	}
+	req.Header.Set("User-Agent", getRandomUserAgentInternal())
+	req.Header.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8")
+	req.Header.Set("Accept-Language", "en-US,en;q=0.9,en-CA;q=0.8")
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return "", &ScrapingError{Op: "Request", URL: url, Kind: kindForError(err), Err: err}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return "", statusError("Request", url, resp.StatusCode, resp.Header.Get("Retry-After"))
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return "", &ScrapingError{Op: "ReadBody", URL: url, Kind: kindForError(err), Err: err}
+	}
+
+	return string(body), nil
+}
@@ -0,0 +1,581 @@
+package scraper
+
+import (
+	"bytes"
+	"context"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/salt-today/salttoday2/internal"
+	"github.com/salt-today/salttoday2/internal/store"
+)
+
+// fixtureServer replays recorded Village Media pages from testdata
+type fixtureServer struct {
+	*httptest.Server
+	dir string
+
+	mu       sync.Mutex
+	requests []string
+}
+
+func newFixtureServer(t *testing.T, site string) *fixtureServer {
+	t.Helper()
+
+	fs := &fixtureServer{dir: filepath.Join("testdata", site)}
+	fs.Server = httptest.NewServer(http.HandlerFunc(fs.serve))
+	t.Cleanup(fs.Close)
+	return fs
+}
+
+func (fs *fixtureServer) serve(w http.ResponseWriter, r *http.Request) {
+	fs.mu.Lock()
+	fs.requests = append(fs.requests, r.URL.RequestURI())
+	fs.mu.Unlock()
+
+	name := fs.fixtureName(r)
+	if name == "" {
+		http.NotFound(w, r)
+		return
+	}
+
+	body, err := os.ReadFile(filepath.Join(fs.dir, name))
+	if os.IsNotExist(err) && r.URL.Path == "/comments/get" {
+		// The comments endpoint returns an empty fragment past the last page
+		w.WriteHeader(http.StatusOK)
+		return
+	} else if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	// Recorded absolute links point back at this server
+	body = bytes.ReplaceAll(body, []byte("SITE_URL"), []byte("http://"+r.Host))
+
+	contentType := "text/html; charset=utf-8"
+	if strings.HasSuffix(name, ".xml") {
+		contentType = "application/xml"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Write(body)
+}
+
+// fixtureName maps a request onto the recorded file that answers it
+func (fs *fixtureServer) fixtureName(r *http.Request) string {
+	query := r.URL.Query()
+
+	switch r.URL.Path {
+	case "/":
+		return "homepage.html"
+	case "/sitemap.xml", "/sitemap-news.xml", "/sitemap-archive.xml":
+		return strings.TrimPrefix(r.URL.Path, "/")
+	case "/rss":
+		return "rss.xml"
+	case "/local-news":
+		if page := query.Get("page"); page != "" && page != "1" {
+			return "category-local-news-page-" + page + ".html"
+		}
+		return "category-local-news.html"
+	case "/comments/get":
+		if parentID := query.Get("ParentId"); parentID != "" {
+			if lastID := query.Get("lastId"); lastID != `""` {
+				return "replies-" + parentID + "-lastid-" + lastID + ".html"
+			}
+			return "replies-" + parentID + ".html"
+		}
+		if lastID := query.Get("lastId"); lastID != "" {
+			return "comments-lastid-" + lastID + ".html"
+		}
+		// Some articles have their own comments recorded, the rest share one set
+		name := "comments-" + query.Get("ContentId") + ".html"
+		if _, err := os.Stat(filepath.Join(fs.dir, name)); err == nil {
+			return name
+		}
+		return "comments.html"
+	default:
+		if userID, ok := strings.CutPrefix(r.URL.Path, "/users/profile/"); ok {
+			return "profile-" + userID + ".html"
+		}
+		if isArticleUrl(r.URL.Path) {
+			return "article-" + r.URL.Path[strings.LastIndex(r.URL.Path, "-")+1:] + ".html"
+		}
+		return ""
+	}
+}
+
+func (fs *fixtureServer) requestsContaining(substr string) int {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	count := 0
+	for _, uri := range fs.requests {
+		if strings.Contains(uri, substr) {
+			count++
+		}
+	}
+	return count
+}
+
+func (fs *fixtureServer) requestCount(path string) int {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	count := 0
+	for _, uri := range fs.requests {
+		if u, err := url.ParseRequestURI(uri); err == nil && u.Path == path {
+			count++
+		}
+	}
+	return count
+}
+
+// fakeStorage is an in-memory store.Storage that records what the scraper writes
+type fakeStorage struct {
+	mu        sync.Mutex
+	articles  map[int]*store.Article
+	comments  map[int]*store.Comment
+	users     map[int]*store.User
+	scrapedAt map[int]time.Time
+	runs      []*store.ScrapeRun
+
+	updatedComments int // Comments stored through UpdateComments
+
+	leases      map[int]fakeLease
+	claims      [][]int // Article IDs asked for by each ClaimArticles call
+	quarantined map[string]string
+
+	commentsErr  map[int]error // AddComments fails for these article IDs
+	commentsGate chan struct{} // When set, AddComments waits to receive from it
+}
+
+var _ store.Storage = (*fakeStorage)(nil)
+
+func newFakeStorage(articles ...*store.Article) *fakeStorage {
+	fs := &fakeStorage{
+		articles:  make(map[int]*store.Article),
+		comments:  make(map[int]*store.Comment),
+		users:     make(map[int]*store.User),
+		scrapedAt: make(map[int]time.Time),
+		leases:    make(map[int]fakeLease),
+
+		quarantined: make(map[string]string),
+	}
+	for _, article := range articles {
+		fs.articles[article.ID] = article
+	}
+	return fs
+}
+
+func (fs *fakeStorage) AddComments(_ context.Context, comments []*store.Comment) error {
+	if fs.commentsGate != nil {
+		<-fs.commentsGate
+	}
+
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	for _, comment := range comments {
+		if err := fs.commentsErr[comment.Article.ID]; err != nil {
+			return err
+		}
+	}
+	for _, comment := range comments {
+		fs.comments[comment.ID] = comment
+	}
+	return nil
+}
+
+func (fs *fakeStorage) UpdateComments(ctx context.Context, comments []*store.Comment) error {
+	fs.mu.Lock()
+	fs.updatedComments += len(comments)
+	fs.mu.Unlock()
+	return fs.AddComments(ctx, comments)
+}
+
+// fakeCommentPageSize is the most comments a GetComments page holds, as in the real storage
+const fakeCommentPageSize = 20
+
+// GetComments pages like the real storage: highest scoring first, at most fakeCommentPageSize at a time
+func (fs *fakeStorage) GetComments(_ context.Context, opts *store.CommentQueryOptions) ([]*store.Comment, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	var comments []*store.Comment
+	for _, comment := range fs.comments {
+		if opts.ArticleID != nil && comment.Article.ID != *opts.ArticleID {
+			continue
+		}
+		comments = append(comments, comment)
+	}
+	sort.Slice(comments, func(i, j int) bool {
+		si, sj := comments[i].Likes+comments[i].Dislikes, comments[j].Likes+comments[j].Dislikes
+		if si != sj {
+			return si > sj
+		}
+		return comments[i].ID < comments[j].ID
+	})
+
+	limit := uint(fakeCommentPageSize)
+	if opts.PageOpts != nil && opts.PageOpts.Limit != nil && *opts.PageOpts.Limit < limit {
+		limit = *opts.PageOpts.Limit
+	}
+	offset := uint(0)
+	if opts.PageOpts != nil && opts.PageOpts.Page != nil {
+		offset = *opts.PageOpts.Page * limit
+	}
+	comments = comments[min(offset, uint(len(comments))):]
+	comments = comments[:min(limit, uint(len(comments)))]
+
+	if len(comments) == 0 {
+		return nil, &store.NoQueryResultsError{}
+	}
+	return comments, nil
+}
+
+func (fs *fakeStorage) GetArticleComments(_ context.Context, articleID int) ([]*store.Comment, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	var comments []*store.Comment
+	for _, comment := range fs.comments {
+		if comment.Article.ID == articleID {
+			comments = append(comments, comment)
+		}
+	}
+	if len(comments) == 0 {
+		return nil, &store.NoQueryResultsError{}
+	}
+	return comments, nil
+}
+
+func (fs *fakeStorage) AddArticles(_ context.Context, articles ...*store.Article) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	for _, article := range articles {
+		if _, ok := fs.articles[article.ID]; !ok {
+			// Like the real storage, new articles haven't been scraped yet
+			stored := *article
+			stored.LastScrapeTime = time.Time{}
+			fs.articles[article.ID] = &stored
+		}
+	}
+	return nil
+}
+
+func (fs *fakeStorage) GetArticles(_ context.Context, articleIDs ...int) ([]*store.Article, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	var articles []*store.Article
+	for _, id := range articleIDs {
+		if article, ok := fs.articles[id]; ok {
+			articles = append(articles, article)
+		}
+	}
+	if len(articles) == 0 {
+		return nil, &store.NoQueryResultsError{}
+	}
+	return articles, nil
+}
+
+func (fs *fakeStorage) GetRecentlyDiscoveredArticles(_ context.Context, threshold time.Time) ([]*store.Article, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	var articles []*store.Article
+	for _, article := range fs.articles {
+		if !article.DiscoveryTime.Before(threshold) {
+			articles = append(articles, article)
+		}
+	}
+	if len(articles) == 0 {
+		return nil, &store.NoQueryResultsError{}
+	}
+	return articles, nil
+}
+
+func (fs *fakeStorage) AddUsers(_ context.Context, users ...*store.User) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	for _, user := range users {
+		if _, ok := fs.users[user.ID]; !ok {
+			fs.users[user.ID] = user
+		}
+	}
+	return nil
+}
+
+func (fs *fakeStorage) GetUsers(context.Context, *store.UserQueryOptions) ([]*store.User, error) {
+	return nil, &store.NoQueryResultsError{}
+}
+
+func (fs *fakeStorage) GetSites(context.Context, *store.PageQueryOptions) ([]*store.Site, error) {
+	return nil, nil
+}
+
+func (fs *fakeStorage) GetTopSite(context.Context, int) (*store.Site, error) {
+	return nil, &store.NoQueryResultsError{}
+}
+
+func (fs *fakeStorage) GetStats(context.Context) (*store.Stats, error) {
+	return &store.Stats{}, nil
+}
+
+func (fs *fakeStorage) SetArticleScrapedAt(_ context.Context, scrapedTime time.Time, articleIDs ...int) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	for _, id := range articleIDs {
+		fs.scrapedAt[id] = scrapedTime
+		if article, ok := fs.articles[id]; ok {
+			article.LastScrapeTime = scrapedTime
+		}
+	}
+	return nil
+}
+
+func (fs *fakeStorage) SetArticleActivity(_ context.Context, articles ...*store.Article) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	for _, article := range articles {
+		fs.scrapedAt[article.ID] = article.LastScrapeTime
+		if stored, ok := fs.articles[article.ID]; ok {
+			stored.LastScrapeTime = article.LastScrapeTime
+			stored.CommentCount = article.CommentCount
+			stored.VoteCount = article.VoteCount
+			stored.ScrapeInterval = article.ScrapeInterval
+			stored.LastActivityTime = article.LastActivityTime
+		}
+	}
+	return nil
+}
+
+func (fs *fakeStorage) SetArticleState(_ context.Context, state string, articleIDs ...int) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	for _, id := range articleIDs {
+		if article, ok := fs.articles[id]; ok {
+			article.State = state
+		}
+	}
+	return nil
+}
+
+type fakeLease struct {
+	owner  string
+	expiry time.Time
+}
+
+func (fs *fakeStorage) ClaimArticles(_ context.Context, owner string, expiry time.Time, articleIDs ...int) ([]int, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	fs.claims = append(fs.claims, slices.Clone(articleIDs))
+
+	var claimed []int
+	for _, id := range articleIDs {
+		lease, ok := fs.leases[id]
+		if ok && lease.owner != owner && lease.expiry.After(time.Now()) {
+			continue
+		}
+		fs.leases[id] = fakeLease{owner: owner, expiry: expiry}
+		claimed = append(claimed, id)
+	}
+	return claimed, nil
+}
+
+func (fs *fakeStorage) RenewArticleLeases(_ context.Context, owner string, expiry time.Time, articleIDs ...int) ([]int, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	var held []int
+	for _, id := range articleIDs {
+		if lease, ok := fs.leases[id]; ok && lease.owner == owner {
+			fs.leases[id] = fakeLease{owner: owner, expiry: expiry}
+			held = append(held, id)
+		}
+	}
+	return held, nil
+}
+
+func (fs *fakeStorage) ReleaseArticles(_ context.Context, owner string, articleIDs ...int) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	for _, id := range articleIDs {
+		if lease, ok := fs.leases[id]; ok && lease.owner == owner {
+			delete(fs.leases, id)
+		}
+	}
+	return nil
+}
+
+func (fs *fakeStorage) GetUsersToProfile(_ context.Context, scrapedBefore time.Time, limit uint) ([]*store.User, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	due := make(map[int]*store.User)
+	for _, comment := range fs.comments {
+		user, ok := fs.users[comment.User.ID]
+		if !ok || (!user.ProfileScrapeTime.IsZero() && !user.ProfileScrapeTime.Before(scrapedBefore)) {
+			continue
+		}
+		if _, ok := due[user.ID]; !ok {
+			profile := *user
+			profile.CommentCount = 0
+			due[user.ID] = &profile
+		}
+		due[user.ID].CommentCount++
+		if article, ok := fs.articles[comment.Article.ID]; ok {
+			due[user.ID].ArticleURL = article.Url
+		}
+	}
+
+	users := make([]*store.User, 0, len(due))
+	for _, user := range due {
+		users = append(users, user)
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].ID < users[j].ID })
+	if uint(len(users)) > limit {
+		users = users[:limit]
+	}
+	if len(users) == 0 {
+		return nil, &store.NoQueryResultsError{}
+	}
+	return users, nil
+}
+
+func (fs *fakeStorage) SetUserProfiles(_ context.Context, users ...*store.User) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	for _, user := range users {
+		if stored, ok := fs.users[user.ID]; ok {
+			stored.JoinTime = user.JoinTime
+			stored.AvatarURL = user.AvatarURL
+			stored.ProfileCommentCount = user.ProfileCommentCount
+			stored.ProfileScrapeTime = user.ProfileScrapeTime
+		}
+	}
+	return nil
+}
+
+func (fs *fakeStorage) SetUserProfileScrapedAt(_ context.Context, scrapedTime time.Time, userIDs ...int) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	for _, id := range userIDs {
+		if stored, ok := fs.users[id]; ok {
+			stored.ProfileScrapeTime = scrapedTime
+		}
+	}
+	return nil
+}
+
+func (fs *fakeStorage) QuarantineSite(_ context.Context, siteName, reason string, _ time.Time) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	if _, ok := fs.quarantined[siteName]; !ok {
+		fs.quarantined[siteName] = reason
+	}
+	return nil
+}
+
+func (fs *fakeStorage) GetQuarantinedSites(context.Context) (map[string]string, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	return maps.Clone(fs.quarantined), nil
+}
+
+func (fs *fakeStorage) ClearSiteQuarantine(_ context.Context, siteName string) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	delete(fs.quarantined, siteName)
+	return nil
+}
+
+func (fs *fakeStorage) AddScrapeRun(_ context.Context, run *store.ScrapeRun) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	run.ID = int64(len(fs.runs) + 1)
+	saved := *run
+	fs.runs = append(fs.runs, &saved)
+	return nil
+}
+
+func (fs *fakeStorage) UpdateScrapeRun(_ context.Context, run *store.ScrapeRun) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	saved := *run
+	fs.runs[run.ID-1] = &saved
+	return nil
+}
+
+func (fs *fakeStorage) GetScrapeRuns(_ context.Context, opts *store.ScrapeRunQueryOptions) ([]*store.ScrapeRun, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	var runs []*store.ScrapeRun
+	for i := len(fs.runs) - 1; i >= 0; i-- {
+		run := *fs.runs[i]
+		if (opts.Kind != "" && run.Kind != opts.Kind) ||
+			(opts.SiteName != "" && run.SiteName != opts.SiteName) ||
+			(opts.Params != "" && run.Params != opts.Params) ||
+			(opts.Unfinished && run.Status == store.RunStatusCompleted) {
+			continue
+		}
+		runs = append(runs, &run)
+	}
+	if len(runs) == 0 {
+		return nil, &store.NoQueryResultsError{}
+	}
+	return runs, nil
+}
+
+func (fs *fakeStorage) commentIDs() []int {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	ids := make([]int, 0, len(fs.comments))
+	for id := range fs.comments {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+// newTestScraper creates a browserless scraper that writes to the given storage
+func newTestScraper(t *testing.T, storage store.Storage) *Scraper {
+	t.Helper()
+
+	config := DefaultConfig()
+	config.DefaultFetchMode = FetchModeHTTP
+	config.MaxArticleWorkers = 2
+	config.MaxCommentWorkers = 2
+	config.NavigationTimeout = 5 * time.Second
+	config.PageLoadTimeout = 5 * time.Second
+	config.RetryDelay = time.Millisecond
+	config.HostRequestsPerSecond = 0
+	config.CrawlDelay = 0
+
+	s, err := NewScraper(context.Background(), config, storage)
+	require.NoError(t, err)
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+// withFixtureSite makes the fixture server the only registered site for the test
+func withFixtureSite(t *testing.T, url string) string {
+	t.Helper()
+
+	sites := internal.Sites
+	site := &internal.Site{Name: "FixtureToday", DisplayName: "FixtureToday", URL: url, Enabled: true}
+	internal.SetSites([]*internal.Site{site})
+	t.Cleanup(func() { internal.SetSites(sites) })
+	return site.Name
+}
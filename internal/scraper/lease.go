@@ -0,0 +1,142 @@
+package scraper
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/salt-today/salttoday2/internal/logger"
+	"github.com/salt-today/salttoday2/internal/store"
+)
+
+// newInstanceID names this process in the lease table, unique even for containers sharing a hostname
+func newInstanceID() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "scraper"
+	}
+	suffix := make([]byte, 4)
+	rand.Read(suffix)
+	return fmt.Sprintf("%s-%d-%s", host, os.Getpid(), hex.EncodeToString(suffix))
+}
+
+// claimArticles leases articles to this scraper so that other scrapers leave them alone. It
+// returns the articles it got, reloaded from storage since another scraper may have just
+// finished with them. The leases are renewed until release is called, and a scraper that
+// crashes holding them loses them once they expire. With LeaseTTL 0, leasing is off.
+func (s *Scraper) claimArticles(ctx context.Context, storage store.Storage, articles []*store.Article) ([]*store.Article, func(), error) {
+	if s.config.LeaseTTL == 0 || len(articles) == 0 {
+		return articles, func() {}, nil
+	}
+
+	ids := make([]int, len(articles))
+	for i, article := range articles {
+		ids[i] = article.ID
+	}
+	claimedIDs, err := storage.ClaimArticles(ctx, s.instanceID, time.Now().Add(s.config.LeaseTTL), ids...)
+	if err != nil {
+		return nil, nil, &ScrapingError{Op: "ClaimArticles", Kind: KindStorage, Err: err}
+	}
+	if len(claimedIDs) == 0 {
+		return nil, func() {}, nil
+	}
+	for _, id := range claimedIDs {
+		s.lostLeases.Delete(id)
+	}
+
+	claimed, err := storage.GetArticles(ctx, claimedIDs...)
+	if err != nil {
+		s.releaseArticles(ctx, storage, claimedIDs)
+		return nil, nil, &ScrapingError{Op: "GetArticles", Kind: KindStorage, Err: err}
+	}
+
+	logger.New(ctx).WithFields(logrus.Fields{
+		"requested": len(ids),
+		"claimed":   len(claimedIDs),
+	}).Debug("Claimed articles")
+
+	heartbeatCtx, stopHeartbeat := context.WithCancel(ctx)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		s.heartbeat(heartbeatCtx, storage, claimedIDs)
+	}()
+
+	var once sync.Once
+	release := func() {
+		once.Do(func() {
+			stopHeartbeat()
+			wg.Wait()
+			s.releaseArticles(ctx, storage, claimedIDs)
+			for _, id := range claimedIDs {
+				s.lostLeases.Delete(id)
+			}
+		})
+	}
+	return claimed, release, nil
+}
+
+// heartbeat renews leases a few times per LeaseTTL until ctx is canceled. Leases that expired
+// and were taken over by another scraper are marked lost, so their results aren't stored.
+func (s *Scraper) heartbeat(ctx context.Context, storage store.Storage, articleIDs []int) {
+	ticker := time.NewTicker(s.config.LeaseTTL / 3)
+	defer ticker.Stop()
+
+	for len(articleIDs) > 0 {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			held, err := storage.RenewArticleLeases(ctx, s.instanceID, time.Now().Add(s.config.LeaseTTL), articleIDs...)
+			if err != nil {
+				logger.New(ctx).WithError(err).Warn("Failed to renew article leases")
+				continue
+			}
+			articleIDs = s.markLostLeases(ctx, articleIDs, held)
+		}
+	}
+}
+
+// markLostLeases marks the articles missing from held as lost, returning those still held
+func (s *Scraper) markLostLeases(ctx context.Context, articleIDs, held []int) []int {
+	if len(held) == len(articleIDs) {
+		return articleIDs
+	}
+
+	stillHeld := make(map[int]bool, len(held))
+	for _, id := range held {
+		stillHeld[id] = true
+	}
+	var lost []int
+	for _, id := range articleIDs {
+		if !stillHeld[id] {
+			s.lostLeases.Store(id, true)
+			lost = append(lost, id)
+		}
+	}
+	logger.New(ctx).WithFields(logrus.Fields{
+		"lost": lost,
+		"held": len(held),
+	}).Warn("Another scraper took over article leases that expired")
+	return held
+}
+
+// leaseLost reports whether another scraper took over the article's lease while this one held it
+func (s *Scraper) leaseLost(articleID int) bool {
+	_, lost := s.lostLeases.Load(articleID)
+	return lost
+}
+
+// releaseArticles gives up leases, logging rather than failing since they expire anyway
+func (s *Scraper) releaseArticles(ctx context.Context, storage store.Storage, articleIDs []int) {
+	if err := storage.ReleaseArticles(context.WithoutCancel(ctx), s.instanceID, articleIDs...); err != nil {
+		logger.New(ctx).WithError(err).Warn("Failed to release article leases, they'll expire instead")
+	}
+}
@@ -0,0 +1,122 @@
+package scraper
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/salt-today/salttoday2/internal/store"
+)
+
+func articleIDs(articles []*store.Article) []int {
+	ids := make([]int, len(articles))
+	for i, article := range articles {
+		ids[i] = article.ID
+	}
+	return ids
+}
+
+func TestClaimArticles(t *testing.T) {
+	ctx := context.Background()
+	articles := []*store.Article{{ID: 1}, {ID: 2}, {ID: 3}}
+	storage := newFakeStorage(articles...)
+
+	first := newTestScraper(t, storage)
+	second := newTestScraper(t, storage)
+	require.NotEqual(t, first.instanceID, second.instanceID)
+
+	claimed, release, err := first.claimArticles(ctx, storage, articles[:2])
+	require.NoError(t, err)
+	require.Equal(t, []int{1, 2}, articleIDs(claimed))
+
+	// The second scraper only gets what the first isn't holding
+	claimed, releaseSecond, err := second.claimArticles(ctx, storage, articles)
+	require.NoError(t, err)
+	require.Equal(t, []int{3}, articleIDs(claimed))
+	releaseSecond()
+
+	// Released leases are free to take
+	release()
+	release()
+	claimed, releaseSecond, err = second.claimArticles(ctx, storage, articles)
+	require.NoError(t, err)
+	require.Equal(t, []int{1, 2, 3}, articleIDs(claimed))
+	releaseSecond()
+
+	// So are leases left behind by a scraper that died
+	storage.leases[2] = fakeLease{owner: "crashed", expiry: time.Now().Add(-time.Second)}
+	claimed, releaseSecond, err = second.claimArticles(ctx, storage, articles)
+	require.NoError(t, err)
+	require.Equal(t, []int{1, 2, 3}, articleIDs(claimed))
+	releaseSecond()
+
+	// Without a TTL there's no leasing at all
+	storage.leases[1] = fakeLease{owner: "other", expiry: time.Now().Add(time.Hour)}
+	second.config.LeaseTTL = 0
+	claimed, releaseSecond, err = second.claimArticles(ctx, storage, articles)
+	require.NoError(t, err)
+	require.Equal(t, []int{1, 2, 3}, articleIDs(claimed))
+	releaseSecond()
+}
+
+func TestClaimArticlesHeartbeat(t *testing.T) {
+	ctx := context.Background()
+	articles := []*store.Article{{ID: 1}}
+	storage := newFakeStorage(articles...)
+	s := newTestScraper(t, storage)
+	s.config.LeaseTTL = 60 * time.Millisecond
+
+	_, release, err := s.claimArticles(ctx, storage, articles)
+	require.NoError(t, err)
+
+	// Leases outlive their TTL while the scraper is still working
+	time.Sleep(150 * time.Millisecond)
+	storage.mu.Lock()
+	lease := storage.leases[1]
+	storage.mu.Unlock()
+	require.Equal(t, s.instanceID, lease.owner)
+	require.True(t, lease.expiry.After(time.Now()))
+
+	release()
+	storage.mu.Lock()
+	require.NotContains(t, storage.leases, 1)
+	storage.mu.Unlock()
+}
+
+func TestClaimArticlesLostLease(t *testing.T) {
+	ctx := context.Background()
+	server := newFixtureServer(t, "villagemedia")
+	articles := []*store.Article{
+		{ID: 1000001, Url: server.URL + "/local-news/city-council-approves-new-budget-1000001"},
+		{ID: 1000012, Url: server.URL + "/local-news/library-hours-1000012"},
+	}
+	storage := newFakeStorage(articles...)
+	s := newTestScraper(t, storage)
+	s.config.LeaseTTL = 30 * time.Millisecond
+
+	claimed, release, err := s.claimArticles(ctx, storage, articles)
+	require.NoError(t, err)
+	defer release()
+
+	// The lease expires during a long scrape and another scraper takes it over
+	storage.mu.Lock()
+	storage.leases[1000001] = fakeLease{owner: "other", expiry: time.Now().Add(time.Hour)}
+	storage.mu.Unlock()
+	require.Eventually(t, func() bool { return s.leaseLost(1000001) }, time.Second, 5*time.Millisecond)
+	require.False(t, s.leaseLost(1000012))
+
+	// Its comments are left for the other scraper to store
+	summary, err := s.scrapeAndStoreCommentsConcurrently(ctx, storage, claimed)
+	require.NoError(t, err)
+	require.Equal(t, map[int]bool{1000001: true}, summary.leaseLost)
+	require.Equal(t, 1, summary.articles)
+	require.NotContains(t, storage.scrapedAt, 1000001)
+	storage.mu.Lock()
+	require.Equal(t, "other", storage.leases[1000001].owner)
+	storage.mu.Unlock()
+
+	release()
+	require.False(t, s.leaseLost(1000001))
+}
@@ -0,0 +1,52 @@
+package scraper
+
+import (
+	"errors"
+
+	"github.com/salt-today/salttoday2/internal/metrics"
+)
+
+var (
+	pagesFetched = metrics.NewCounterVec("salttoday_scraper_pages_fetched_total",
+		"Pages fetched, by site and whether the fetch succeeded", "site", "result")
+	scrapeErrors = metrics.NewCounterVec("salttoday_scraper_errors_total",
+		"Scraping failures, by the operation that failed", "op")
+	parseFallbacks = metrics.NewCounterVec("salttoday_scraper_parse_fallbacks_total",
+		"Comment fields that couldn't be parsed and fell back to a default", "field")
+	commentsFound = metrics.NewCounterVec("salttoday_scraper_comments_found_total",
+		"Comments scraped and stored, by site", "site")
+	browserContexts = metrics.NewGaugeVec("salttoday_scraper_browser_contexts",
+		"Open browser contexts, by whether they're idle in the pool or in use", "state")
+	alertsRaised = metrics.NewCounterVec("salttoday_scraper_alerts_total",
+		"Alerts sent, by kind", "kind")
+	quarantinedSites = metrics.NewGaugeVec("salttoday_scraper_quarantined_sites",
+		"Sites whose comments aren't being stored because their parser drifted", "site")
+	profilesScraped = metrics.NewCounterVec("salttoday_scraper_profiles_scraped_total",
+		"Commenter profiles scraped, by site and whether the scrape succeeded", "site", "result")
+	profileCommentsMissing = metrics.NewCounterVec("salttoday_scraper_profile_comments_missing_total",
+		"Comments counted on commenters' profiles beyond those stored, by site", "site")
+	browserContextsClosed = metrics.NewCounterVec("salttoday_scraper_browser_contexts_closed_total",
+		"Browser contexts closed, by why: recycled, unhealthy or closed", "reason")
+	browserRelaunches = metrics.NewCounterVec("salttoday_scraper_browser_relaunches_total",
+		"Times the browser was relaunched after disconnecting")
+	browserWait = metrics.NewHistogramVec("salttoday_scraper_browser_wait_seconds",
+		"Time taken to get a browser context from the pool", metrics.DefBuckets)
+)
+
+// countError records a failure under its ScrapingError operation
+func countError(err error) {
+	op := "unknown"
+	var scrapingErr *ScrapingError
+	if errors.As(err, &scrapingErr) {
+		op = scrapingErr.Op
+	}
+	scrapeErrors.With(op).Inc()
+}
+
+// metricSite is the site label for a URL, keeping unregistered hosts from adding series
+func metricSite(pageURL string) string {
+	if name := siteNameForURL(pageURL); name != "" {
+		return name
+	}
+	return "other"
+}
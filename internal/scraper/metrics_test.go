@@ -0,0 +1,34 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/salt-today/salttoday2/internal/metrics"
+)
+
+func TestScraperMetrics(t *testing.T) {
+	server := newFixtureServer(t, "villagemedia")
+	withFixtureSite(t, server.URL)
+	s := newTestScraper(t, newFakeStorage())
+
+	_, err := s.fetchDocument(context.Background(), server.URL+"/", s.config.PageLoadTimeout)
+	require.NoError(t, err)
+	_, err = s.fetchDocument(context.Background(), server.URL+"/missing", s.config.PageLoadTimeout)
+	require.Error(t, err)
+
+	countError(&ScrapingError{Op: "StoreComments", Kind: KindStorage, Err: errors.New("disk full")})
+	countError(errors.New("not a scraping error"))
+
+	var out strings.Builder
+	_, err = metrics.DefaultRegistry.WriteTo(&out)
+	require.NoError(t, err)
+	require.Contains(t, out.String(), `salttoday_scraper_pages_fetched_total{site="FixtureToday",result="ok"}`)
+	require.Contains(t, out.String(), `salttoday_scraper_pages_fetched_total{site="FixtureToday",result="error"}`)
+	require.Contains(t, out.String(), `salttoday_scraper_errors_total{op="StoreComments"}`)
+	require.Contains(t, out.String(), `salttoday_scraper_errors_total{op="unknown"}`)
+}
@@ -0,0 +1,109 @@
+package scraper
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/salt-today/salttoday2/internal/logger"
+)
+
+// Alert is a problem that needs someone to look at it, like a site changing its markup
+type Alert struct {
+	Site    string
+	Kind    string
+	Message string
+	Time    time.Time
+}
+
+// Kinds of alert
+const (
+	AlertParserDrift = "parser_drift"
+	AlertNoArticles  = "no_articles"
+)
+
+// Notifier delivers alerts
+type Notifier interface {
+	Notify(ctx context.Context, alert Alert) error
+}
+
+// LogNotifier writes alerts to the log, it's used when nothing else is configured
+type LogNotifier struct{}
+
+// Notify logs the alert as a warning
+func (LogNotifier) Notify(ctx context.Context, alert Alert) error {
+	logger.New(ctx).WithFields(logrus.Fields{
+		"site": alert.Site,
+		"kind": alert.Kind,
+	}).Warn(alert.Message)
+	return nil
+}
+
+// WebhookNotifier posts alerts as JSON with a text field, the format Slack and Discord style webhooks accept
+type WebhookNotifier struct {
+	url    string
+	client *http.Client
+}
+
+// NewWebhookNotifier creates a notifier posting to the webhook URL
+func NewWebhookNotifier(url string) *WebhookNotifier {
+	return &WebhookNotifier{url: url, client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+// Notify posts the alert to the webhook
+func (n *WebhookNotifier) Notify(ctx context.Context, alert Alert) error {
+	body, err := json.Marshal(map[string]string{
+		"text": fmt.Sprintf("[salttoday] %s on %s: %s", alert.Kind, alert.Site, alert.Message),
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
+
+// SetNotifier changes where alerts are sent
+func (s *Scraper) SetNotifier(notifier Notifier) {
+	s.alertMu.Lock()
+	defer s.alertMu.Unlock()
+	s.notifier = notifier
+}
+
+// alert sends an alert, unless the same kind was sent for the site within AlertCooldown
+func (s *Scraper) alert(ctx context.Context, alert Alert) {
+	key := alert.Site + "/" + alert.Kind
+
+	s.alertMu.Lock()
+	if last, ok := s.alerted[key]; ok && alert.Time.Sub(last) < s.config.AlertCooldown {
+		s.alertMu.Unlock()
+		return
+	}
+	s.alerted[key] = alert.Time
+	notifier := s.notifier
+	s.alertMu.Unlock()
+
+	alertsRaised.With(alert.Kind).Inc()
+	if err := notifier.Notify(ctx, alert); err != nil {
+		logger.New(ctx).WithError(err).WithField("site", alert.Site).Error("Failed to send alert")
+	}
+}
@@ -0,0 +1,109 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+
+	"github.com/salt-today/salttoday2/internal"
+	"github.com/salt-today/salttoday2/internal/store"
+)
+
+// DocumentFetcher fetches and parses a page through the scraper's fetch chain
+type DocumentFetcher func(ctx context.Context, url string, timeout time.Duration) (*goquery.Document, error)
+
+// SiteParser understands one site layout: where its articles are linked and how its comments are loaded
+type SiteParser interface {
+	// ParseArticles extracts the articles linked from a homepage or category listing
+	ParseArticles(ctx context.Context, doc *goquery.Document, siteURL string) map[int]*store.Article
+
+	// ParseListingTimes returns the publish times shown next to articles in a listing, keyed by article ID
+	ParseListingTimes(ctx context.Context, doc *goquery.Document) map[int]time.Time
+
+	// ListComments fetches every comment on an article, including replies, recording commenters in users
+	ListComments(ctx context.Context, fetch DocumentFetcher, article *store.Article, users map[int]string) ([]*store.Comment, error)
+
+	// FetchReplies fetches the replies to one comment
+	FetchReplies(ctx context.Context, fetch DocumentFetcher, article *store.Article, parentID string, users map[int]string) ([]*store.Comment, error)
+
+	// ArticleState checks whether an article is still up and taking comments, returning one of the
+	// store.ArticleState values, or empty if it is
+	ArticleState(ctx context.Context, fetch DocumentFetcher, article *store.Article) (string, error)
+
+	// FetchProfile fills in the user's profile fields from their profile page on the site
+	FetchProfile(ctx context.Context, fetch DocumentFetcher, siteURL string, user *store.User) error
+}
+
+// SiteParserFactory creates a parser for a site. site is nil for URLs outside the site registry.
+type SiteParserFactory func(site *internal.Site, config *ScrapingConfig) SiteParser
+
+// DefaultSiteParser is used by sites that don't name a parser
+const DefaultSiteParser = "villagemedia"
+
+var (
+	siteParsersMu sync.RWMutex
+	siteParsers   = map[string]SiteParserFactory{
+		DefaultSiteParser: NewVillageMediaParser,
+	}
+)
+
+// RegisterSiteParser makes a parser available to sites that name it in the site registry
+func RegisterSiteParser(name string, factory SiteParserFactory) {
+	siteParsersMu.Lock()
+	defer siteParsersMu.Unlock()
+	siteParsers[name] = factory
+}
+
+// SiteParserNames returns the names of every registered parser
+func SiteParserNames() []string {
+	siteParsersMu.RLock()
+	defer siteParsersMu.RUnlock()
+
+	names := make([]string, 0, len(siteParsers))
+	for name := range siteParsers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// siteParserFactory returns the factory for the named parser, or the default for ""
+func siteParserFactory(name string) (SiteParserFactory, bool) {
+	if name == "" {
+		name = DefaultSiteParser
+	}
+
+	siteParsersMu.RLock()
+	defer siteParsersMu.RUnlock()
+	factory, ok := siteParsers[name]
+	return factory, ok
+}
+
+// checkSiteParsers makes sure every enabled site names a registered parser
+func checkSiteParsers() error {
+	for _, site := range internal.EnabledSites() {
+		if _, ok := siteParserFactory(site.Parser); !ok {
+			return fmt.Errorf("site %s uses unknown parser %q, registered parsers are %v", site.Name, site.Parser, SiteParserNames())
+		}
+	}
+	return nil
+}
+
+// parserFor returns the parser for the site serving the URL
+func (s *Scraper) parserFor(pageURL string) SiteParser {
+	site := siteForURL(pageURL)
+
+	var name string
+	if site != nil {
+		name = site.Parser
+	}
+	factory, ok := siteParserFactory(name)
+	if !ok {
+		factory, _ = siteParserFactory(DefaultSiteParser)
+	}
+	return factory(site, s.config)
+}
@@ -0,0 +1,76 @@
+package scraper
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/stretchr/testify/require"
+
+	"github.com/salt-today/salttoday2/internal"
+	"github.com/salt-today/salttoday2/internal/store"
+)
+
+// stubParser answers every article with one canned comment
+type stubParser struct {
+	site *internal.Site
+}
+
+func (p *stubParser) ParseArticles(context.Context, *goquery.Document, string) map[int]*store.Article {
+	return nil
+}
+
+func (p *stubParser) ParseListingTimes(context.Context, *goquery.Document) map[int]time.Time {
+	return nil
+}
+
+func (p *stubParser) ListComments(_ context.Context, _ DocumentFetcher, article *store.Article, users map[int]string) ([]*store.Comment, error) {
+	users[1] = p.site.Name
+	return []*store.Comment{{ID: 1, Article: store.Article{ID: article.ID}, User: store.User{ID: 1}}}, nil
+}
+
+func (p *stubParser) FetchReplies(context.Context, DocumentFetcher, *store.Article, string, map[int]string) ([]*store.Comment, error) {
+	return nil, nil
+}
+
+func (p *stubParser) ArticleState(context.Context, DocumentFetcher, *store.Article) (string, error) {
+	return "", nil
+}
+
+func (p *stubParser) FetchProfile(context.Context, DocumentFetcher, string, *store.User) error {
+	return nil
+}
+
+func TestSiteParserRegistry(t *testing.T) {
+	RegisterSiteParser("stub", func(site *internal.Site, _ *ScrapingConfig) SiteParser {
+		return &stubParser{site: site}
+	})
+	t.Cleanup(func() {
+		siteParsersMu.Lock()
+		delete(siteParsers, "stub")
+		siteParsersMu.Unlock()
+	})
+	require.Contains(t, SiteParserNames(), "stub")
+
+	sites := internal.Sites
+	stubSite := &internal.Site{Name: "StubToday", URL: "https://www.stubtoday.ca", Enabled: true, Parser: "stub"}
+	internal.SetSites(append(slices.Clone(sites), stubSite))
+	t.Cleanup(func() { internal.SetSites(sites) })
+
+	s := newTestScraper(t, newFakeStorage())
+	users := make(map[int]string)
+	comments, err := s.ScrapeCommentsFromArticle(context.Background(), &store.Article{ID: 7, Url: "https://www.stubtoday.ca/local-news/a-7"}, users)
+	require.NoError(t, err)
+	require.Len(t, comments, 1)
+	require.Equal(t, map[int]string{1: "StubToday"}, users)
+
+	// Sites outside the registry, or that don't name a parser, get the Village Media parser
+	require.IsType(t, &VillageMediaParser{}, s.parserFor("https://www.sootoday.com/local-news/a-1"))
+	require.IsType(t, &VillageMediaParser{}, s.parserFor("http://127.0.0.1/local-news/a-1"))
+
+	// Enabled sites must name a registered parser
+	stubSite.Parser = "missing"
+	require.Error(t, checkSiteParsers())
+}
@@ -0,0 +1,112 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/salt-today/salttoday2/internal/logger"
+	"github.com/salt-today/salttoday2/internal/store"
+)
+
+// ScrapeAndStoreProfiles scrapes the profiles of up to MaxProfilesPerPoll commenters whose
+// profile is missing or older than ProfileRefreshInterval
+func (s *Scraper) ScrapeAndStoreProfiles(ctx context.Context) error {
+	storage, err := s.requireStorage()
+	if err != nil {
+		return err
+	}
+	_, err = s.scrapeAndStoreProfiles(ctx, storage)
+	return err
+}
+
+// scrapeAndStoreProfiles scrapes the profiles due a refresh, returning how many were stored.
+// A profile counting more comments than are stored means comments were missed, which is
+// logged and counted per site. Profiles that fail to scrape have the attempt recorded, so they
+// wait for the next refresh instead of taking the front of the queue every poll.
+func (s *Scraper) scrapeAndStoreProfiles(ctx context.Context, storage store.Storage) (int, error) {
+	logEntry := logger.New(ctx).WithField("operation", "scrape_profiles")
+
+	users, err := storage.GetUsersToProfile(ctx, time.Now().Add(-s.config.ProfileRefreshInterval), uint(s.config.MaxProfilesPerPoll))
+	var noResults *store.NoQueryResultsError
+	if errors.As(err, &noResults) {
+		return 0, nil
+	} else if err != nil {
+		return 0, &ScrapingError{Op: "GetUsersToProfile", Kind: KindStorage, Err: err}
+	}
+
+	var scraped []*store.User
+	var failed []int
+	for _, user := range users {
+		if ctx.Err() != nil {
+			break
+		}
+
+		siteURL, err := getBaseUrl(user.ArticleURL)
+		if err != nil {
+			failed = append(failed, user.ID)
+			continue
+		}
+		site := metricSite(user.ArticleURL)
+
+		err = s.parserFor(user.ArticleURL).FetchProfile(ctx, s.fetchDocument, siteURL, user)
+		if err != nil && ErrorKindOf(err) != KindNotFound {
+			logEntry.WithError(err).WithField("user_id", user.ID).Warn("Failed to scrape profile")
+			countError(err)
+			profilesScraped.With(site, "error").Inc()
+			failed = append(failed, user.ID)
+			continue
+		}
+		// Profiles that are gone are marked scraped too, so they aren't tried every poll
+		user.ProfileScrapeTime = time.Now()
+		scraped = append(scraped, user)
+		profilesScraped.With(site, "ok").Inc()
+
+		if missing := user.ProfileCommentCount - user.CommentCount; err == nil && missing > 0 {
+			logEntry.WithFields(logrus.Fields{
+				"user_id":          user.ID,
+				"profile_comments": user.ProfileCommentCount,
+				"stored_comments":  user.CommentCount,
+			}).Info("Profile shows comments that were never scraped")
+			profileCommentsMissing.With(site).Add(float64(missing))
+		}
+	}
+
+	if len(scraped) > 0 {
+		if err := storage.SetUserProfiles(ctx, scraped...); err != nil {
+			return 0, &ScrapingError{Op: "StoreProfiles", Kind: KindStorage, Err: err}
+		}
+	}
+	if len(failed) > 0 {
+		if err := storage.SetUserProfileScrapedAt(ctx, time.Now(), failed...); err != nil {
+			return 0, &ScrapingError{Op: "StoreProfileAttempts", Kind: KindStorage, Err: err}
+		}
+	}
+
+	logEntry.WithFields(logrus.Fields{
+		"due":     len(users),
+		"scraped": len(scraped),
+		"failed":  len(failed),
+	}).Info("Profile scraping completed")
+	return len(scraped), nil
+}
+
+// pollProfiles scrapes profiles due a refresh every ProfilePollInterval until ctx is canceled
+func (s *Scraper) pollProfiles(ctx, workCtx context.Context, storage store.Storage) {
+	ticker := time.NewTicker(s.config.ProfilePollInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.scrapeAndStoreProfiles(workCtx, storage); err != nil {
+			logger.New(ctx).WithError(err).Error("Failed to scrape profiles")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
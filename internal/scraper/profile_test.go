@@ -0,0 +1,79 @@
+package scraper
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/salt-today/salttoday2/internal/store"
+)
+
+func TestScrapeAndStoreProfiles(t *testing.T) {
+	server := newFixtureServer(t, "villagemedia")
+	article := &store.Article{ID: 1000001, Url: server.URL + "/local-news/city-council-approves-new-budget-1000001"}
+	storage := newFakeStorage(article)
+	for id, name := range map[int]string{5001: "alice", 5002: "bob", 5003: "carol"} {
+		storage.users[id] = &store.User{ID: id, UserName: name}
+	}
+	for i, userID := range []int{5001, 5002, 5003} {
+		storage.comments[101+i] = &store.Comment{ID: 101 + i, Article: store.Article{ID: article.ID}, User: store.User{ID: userID}}
+	}
+	s := newTestScraper(t, storage)
+
+	scraped, err := s.scrapeAndStoreProfiles(context.Background(), storage)
+	require.NoError(t, err)
+	require.Equal(t, 3, scraped)
+
+	alice := storage.users[5001]
+	require.Equal(t, time.Date(2019, 3, 14, 0, 0, 0, 0, time.UTC), alice.JoinTime)
+	require.Equal(t, server.URL+"/avatars/5001.png", alice.AvatarURL)
+	require.Equal(t, 3, alice.ProfileCommentCount)
+	require.Equal(t, "https://cdn.example.com/avatars/5002.jpg", storage.users[5002].AvatarURL)
+
+	// A missing profile isn't retried every poll
+	carol := storage.users[5003]
+	require.False(t, carol.ProfileScrapeTime.IsZero())
+	require.True(t, carol.JoinTime.IsZero())
+
+	// Nothing is due again until the refresh interval passes
+	scraped, err = s.scrapeAndStoreProfiles(context.Background(), storage)
+	require.NoError(t, err)
+	require.Zero(t, scraped)
+	require.Equal(t, 1, server.requestCount("/users/profile/5001"))
+}
+
+func TestScrapeAndStoreProfilesRecordsFailures(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/users/profile/5001" {
+			requests.Add(1)
+		}
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	t.Cleanup(server.Close)
+
+	article := &store.Article{ID: 1000001, Url: server.URL + "/local-news/city-council-approves-new-budget-1000001"}
+	storage := newFakeStorage(article)
+	storage.users[5001] = &store.User{ID: 5001, UserName: "alice", AvatarURL: "https://cdn.example.com/avatars/5001.jpg"}
+	storage.comments[101] = &store.Comment{ID: 101, Article: store.Article{ID: article.ID}, User: store.User{ID: 5001}}
+	s := newTestScraper(t, storage)
+
+	scraped, err := s.scrapeAndStoreProfiles(context.Background(), storage)
+	require.NoError(t, err)
+	require.Zero(t, scraped)
+
+	// The attempt is recorded without losing what an earlier scrape stored
+	alice := storage.users[5001]
+	require.False(t, alice.ProfileScrapeTime.IsZero())
+	require.Equal(t, "https://cdn.example.com/avatars/5001.jpg", alice.AvatarURL)
+
+	// So it isn't retried until the refresh interval passes
+	_, err = s.scrapeAndStoreProfiles(context.Background(), storage)
+	require.NoError(t, err)
+	require.Equal(t, int32(1), requests.Load())
+}
@@ -0,0 +1,185 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// HostLimiter enforces per-host politeness: a token bucket, a cap on concurrent
+// requests, a minimum delay between requests, and pauses requested via Retry-After
+type HostLimiter struct {
+	rate          float64 // Tokens added per second, 0 for unlimited
+	burst         int
+	maxConcurrent int
+	crawlDelay    time.Duration
+
+	mu    sync.Mutex
+	hosts map[string]*hostState
+}
+
+// hostState tracks the politeness budget of a single host
+type hostState struct {
+	tokens      float64
+	refilledAt  time.Time
+	lastRequest time.Time
+	crawlDelay  time.Duration
+	pausedUntil time.Time
+	slots       chan struct{}
+}
+
+// NewHostLimiter creates a limiter from the politeness settings in config
+func NewHostLimiter(config *ScrapingConfig) *HostLimiter {
+	burst := config.HostBurst
+	if burst < 1 {
+		burst = 1
+	}
+	return &HostLimiter{
+		rate:          config.HostRequestsPerSecond,
+		burst:         burst,
+		maxConcurrent: config.MaxConcurrentPerHost,
+		crawlDelay:    config.CrawlDelay,
+		hosts:         make(map[string]*hostState),
+	}
+}
+
+// host returns the state for a host, creating it on first use. Callers must hold mu.
+func (l *HostLimiter) host(name string) *hostState {
+	state, ok := l.hosts[name]
+	if !ok {
+		state = &hostState{
+			tokens:     float64(l.burst),
+			refilledAt: time.Now(),
+			crawlDelay: l.crawlDelay,
+		}
+		if l.maxConcurrent > 0 {
+			state.slots = make(chan struct{}, l.maxConcurrent)
+		}
+		l.hosts[name] = state
+	}
+	return state
+}
+
+// Acquire blocks until a request to host is allowed, returning a func that must be called when the request finishes
+func (l *HostLimiter) Acquire(ctx context.Context, host string) (func(), error) {
+	l.mu.Lock()
+	slots := l.host(host).slots
+	l.mu.Unlock()
+
+	release := func() {}
+	if slots != nil {
+		select {
+		case slots <- struct{}{}:
+			release = func() { <-slots }
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	for {
+		wait := l.reserve(host)
+		if wait <= 0 {
+			return release, nil
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			release()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// reserve takes a token for host if one is available now, otherwise returns how long to wait
+func (l *HostLimiter) reserve(host string) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	state := l.host(host)
+	now := time.Now()
+
+	if l.rate > 0 {
+		state.tokens += now.Sub(state.refilledAt).Seconds() * l.rate
+		if state.tokens > float64(l.burst) {
+			state.tokens = float64(l.burst)
+		}
+	}
+	state.refilledAt = now
+
+	wait := state.pausedUntil.Sub(now)
+	if delayWait := state.lastRequest.Add(state.crawlDelay).Sub(now); delayWait > wait {
+		wait = delayWait
+	}
+	if l.rate > 0 && state.tokens < 1 {
+		if tokenWait := time.Duration((1 - state.tokens) / l.rate * float64(time.Second)); tokenWait > wait {
+			wait = tokenWait
+		}
+	}
+	if wait > 0 {
+		return wait
+	}
+
+	if l.rate > 0 {
+		state.tokens--
+	}
+	state.lastRequest = now
+	return 0
+}
+
+// Pause stops all requests to host until the given time
+func (l *HostLimiter) Pause(host string, until time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if state := l.host(host); until.After(state.pausedUntil) {
+		state.pausedUntil = until
+	}
+}
+
+// SetCrawlDelay raises the minimum delay between requests to host
+func (l *HostLimiter) SetCrawlDelay(host string, delay time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if state := l.host(host); delay > state.crawlDelay {
+		state.crawlDelay = delay
+	}
+}
+
+// RateLimitedFetcher holds every request to another fetcher to its host's politeness budget
+type RateLimitedFetcher struct {
+	next    Fetcher
+	limiter *HostLimiter
+}
+
+// NewRateLimitedFetcher wraps next so that requests go through limiter
+func NewRateLimitedFetcher(next Fetcher, limiter *HostLimiter) *RateLimitedFetcher {
+	return &RateLimitedFetcher{next: next, limiter: limiter}
+}
+
+// Fetch waits for the host's budget, fetches the URL, and honours any Retry-After in the response
+func (f *RateLimitedFetcher) Fetch(ctx context.Context, pageURL string, timeout time.Duration) (string, error) {
+	u, err := url.Parse(pageURL)
+	if err != nil {
+		return "", &ScrapingError{Op: "ParseURL", URL: pageURL, Kind: KindParse, Err: err}
+	}
+
+	release, err := f.limiter.Acquire(ctx, u.Host)
+	if err != nil {
+		return "", &ScrapingError{Op: "RateLimit", URL: pageURL, Kind: KindCanceled, Err: err}
+	}
+	defer release()
+
+	content, err := f.next.Fetch(ctx, pageURL, timeout)
+
+	var scrapingErr *ScrapingError
+	if errors.As(err, &scrapingErr) && scrapingErr.RetryAfter > 0 {
+		f.limiter.Pause(u.Host, time.Now().Add(scrapingErr.RetryAfter))
+	}
+	return content, err
+}
@@ -0,0 +1,88 @@
+package scraper
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHostLimiterSpacesRequests(t *testing.T) {
+	limiter := NewHostLimiter(&ScrapingConfig{HostRequestsPerSecond: 100, HostBurst: 1, CrawlDelay: 20 * time.Millisecond})
+
+	start := time.Now()
+	for range 3 {
+		release, err := limiter.Acquire(context.Background(), "example.com")
+		require.NoError(t, err)
+		release()
+	}
+	require.GreaterOrEqual(t, time.Since(start), 40*time.Millisecond)
+
+	// Other hosts have their own budget
+	start = time.Now()
+	release, err := limiter.Acquire(context.Background(), "example.org")
+	require.NoError(t, err)
+	release()
+	require.Less(t, time.Since(start), 20*time.Millisecond)
+}
+
+func TestHostLimiterCapsConcurrency(t *testing.T) {
+	limiter := NewHostLimiter(&ScrapingConfig{MaxConcurrentPerHost: 2})
+
+	var inFlight, peak atomic.Int32
+	var wg sync.WaitGroup
+	errs := make(chan error, 8)
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			release, err := limiter.Acquire(context.Background(), "example.com")
+			if err != nil {
+				errs <- err
+				return
+			}
+			defer release()
+
+			n := inFlight.Add(1)
+			for {
+				p := peak.Load()
+				if n <= p || peak.CompareAndSwap(p, n) {
+					break
+				}
+			}
+			time.Sleep(5 * time.Millisecond)
+			inFlight.Add(-1)
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		require.NoError(t, err)
+	}
+	require.Equal(t, int32(2), peak.Load())
+}
+
+func TestRateLimitedFetcherHonoursRetryAfter(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "1")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	limiter := NewHostLimiter(&ScrapingConfig{})
+	fetcher := NewRateLimitedFetcher(NewHTTPFetcher(), limiter)
+
+	_, err := fetcher.Fetch(context.Background(), server.URL, time.Second)
+	require.Equal(t, KindRateLimited, ErrorKindOf(err))
+
+	// The host stays paused until Retry-After has passed
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	_, err = limiter.Acquire(ctx, server.Listener.Addr().String())
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
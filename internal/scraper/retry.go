@@ -0,0 +1,94 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand/v2"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/salt-today/salttoday2/internal/logger"
+)
+
+// RetryPolicy decides how many times, and how far apart, retryable failures are attempted again
+type RetryPolicy struct {
+	MaxRetries      int
+	InitialDelay    time.Duration
+	BackoffMultiple float64
+	MaxDelay        time.Duration
+}
+
+// Backoff returns the jittered delay to wait before the given retry, starting at 1
+func (p RetryPolicy) Backoff(retry int) time.Duration {
+	multiple := p.BackoffMultiple
+	if multiple < 1 {
+		multiple = 1
+	}
+
+	delay := float64(p.InitialDelay) * math.Pow(multiple, float64(retry-1))
+	if p.MaxDelay > 0 && delay > float64(p.MaxDelay) {
+		delay = float64(p.MaxDelay)
+	}
+
+	// Half fixed, half random, so workers that failed together don't retry together
+	return time.Duration(delay/2 + rand.Float64()*delay/2)
+}
+
+// Do runs fn until it succeeds, fails permanently, or runs out of retries
+func (p RetryPolicy) Do(ctx context.Context, fn func() error) error {
+	logEntry := logger.New(ctx)
+
+	var err error
+	for attempt := 0; ; attempt++ {
+		err = fn()
+		if err == nil || !IsRetryable(err) || attempt >= p.MaxRetries {
+			return err
+		}
+
+		delay := p.Backoff(attempt + 1)
+		var scrapingErr *ScrapingError
+		if errors.As(err, &scrapingErr) && scrapingErr.RetryAfter > delay {
+			delay = scrapingErr.RetryAfter // The server knows better than our backoff
+			if p.MaxDelay > 0 && delay > p.MaxDelay {
+				delay = p.MaxDelay // But a worker isn't tied up for however long it asks
+			}
+		}
+		logEntry.WithError(err).WithFields(logrus.Fields{
+			"attempt": attempt + 1,
+			"kind":    ErrorKindOf(err),
+			"delay":   delay,
+		}).Warn("Retryable scraping failure, backing off")
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+// RetryingFetcher retries another fetcher's retryable failures according to a policy
+type RetryingFetcher struct {
+	next   Fetcher
+	policy RetryPolicy
+}
+
+// NewRetryingFetcher wraps next with the given retry policy
+func NewRetryingFetcher(next Fetcher, policy RetryPolicy) *RetryingFetcher {
+	return &RetryingFetcher{next: next, policy: policy}
+}
+
+// Fetch fetches the URL, retrying timeouts, network errors, 5xx and 429 responses
+func (f *RetryingFetcher) Fetch(ctx context.Context, url string, timeout time.Duration) (string, error) {
+	var content string
+	err := f.policy.Do(ctx, func() error {
+		var err error
+		content, err = f.next.Fetch(ctx, url, timeout)
+		return err
+	})
+	return content, err
+}
@@ -0,0 +1,96 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/playwright-community/playwright-go"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetryingFetcher(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := calls.Add(1)
+		switch {
+		case r.URL.Path == "/missing":
+			http.NotFound(w, r)
+		case n <= 2:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			w.Write([]byte("<p>ok</p>"))
+		}
+	}))
+	defer server.Close()
+
+	fetcher := NewRetryingFetcher(NewHTTPFetcher(), RetryPolicy{MaxRetries: 3, InitialDelay: time.Millisecond, BackoffMultiple: 2})
+
+	// 5xx responses are retried until the server recovers
+	content, err := fetcher.Fetch(context.Background(), server.URL+"/flaky", time.Second)
+	require.NoError(t, err)
+	require.Equal(t, "<p>ok</p>", content)
+	require.Equal(t, int32(3), calls.Load())
+
+	// 404s are permanent and fail on the first attempt
+	calls.Store(0)
+	_, err = fetcher.Fetch(context.Background(), server.URL+"/missing", time.Second)
+	require.Equal(t, KindNotFound, ErrorKindOf(err))
+	require.False(t, IsRetryable(err))
+	require.Equal(t, int32(1), calls.Load())
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	policy := RetryPolicy{InitialDelay: 100 * time.Millisecond, BackoffMultiple: 2, MaxDelay: time.Second}
+
+	for retry, full := range map[int]time.Duration{1: 100 * time.Millisecond, 3: 400 * time.Millisecond, 10: time.Second} {
+		delay := policy.Backoff(retry)
+		require.GreaterOrEqual(t, delay, full/2)
+		require.LessOrEqual(t, delay, full)
+	}
+}
+
+func TestRetryPolicyCapsRetryAfter(t *testing.T) {
+	policy := RetryPolicy{MaxRetries: 1, InitialDelay: time.Millisecond, BackoffMultiple: 2, MaxDelay: 10 * time.Millisecond}
+
+	calls := 0
+	start := time.Now()
+	err := policy.Do(context.Background(), func() error {
+		calls++
+		if calls == 1 {
+			return &ScrapingError{Op: "Request", Kind: KindRateLimited, RetryAfter: time.Hour}
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, 2, calls)
+	require.Less(t, time.Since(start), time.Second)
+}
+
+func TestKindForError(t *testing.T) {
+	tests := map[string]struct {
+		err      error
+		expected ErrorKind
+	}{
+		"canceled":         {err: context.Canceled, expected: KindCanceled},
+		"deadline":         {err: fmt.Errorf("get: %w", context.DeadlineExceeded), expected: KindTimeout},
+		"playwright":       {err: playwright.ErrTimeout, expected: KindTimeout},
+		"connection":       {err: &net.OpError{Op: "dial", Err: errors.New("connection refused")}, expected: KindNetwork},
+		"truncated body":   {err: io.ErrUnexpectedEOF, expected: KindNetwork},
+		"chromium network": {err: errors.New("page.goto: net::ERR_CONNECTION_RESET at https://www.sootoday.com/"), expected: KindNetwork},
+		"unrecognised":     {err: errors.New("page.goto: Target page, context or browser has been closed"), expected: KindUnknown},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, tc.expected, kindForError(tc.err))
+		})
+	}
+	require.False(t, KindUnknown.Retryable())
+}
@@ -0,0 +1,298 @@
+package scraper
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/salt-today/salttoday2/internal/logger"
+)
+
+// robotsFailureTTL is how long an unreachable robots.txt is cached before we try again
+const robotsFailureTTL = 5 * time.Minute
+
+// robotsRule is a single Allow or Disallow line
+type robotsRule struct {
+	pattern string
+	allow   bool
+	re      *regexp.Regexp
+}
+
+// RobotsRules are the robots.txt rules that apply to our user agent on one host
+type RobotsRules struct {
+	rules      []robotsRule
+	crawlDelay time.Duration
+}
+
+// allowAll and disallowAll are used when robots.txt is missing or unreachable, per RFC 9309
+var (
+	allowAll    = &RobotsRules{}
+	disallowAll = &RobotsRules{rules: []robotsRule{newRobotsRule("/", false)}}
+)
+
+// ParseRobots parses a robots.txt body, keeping the group for userAgent or, failing that, the "*" group.
+// Groups are matched on the product token alone, case-insensitively, as RFC 9309 describes.
+func ParseRobots(body, userAgent string) *RobotsRules {
+	product := productToken(userAgent)
+
+	var (
+		specific, wildcard *RobotsRules
+		current            []*RobotsRules // Groups the lines being read apply to
+		inAgents           bool           // Whether the previous line was a user-agent line
+	)
+
+	scanner := bufio.NewScanner(strings.NewReader(body))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		if key == "user-agent" {
+			if !inAgents {
+				current = nil
+			}
+			inAgents = true
+
+			agent := productToken(value)
+			switch {
+			case agent == "*":
+				if wildcard == nil {
+					wildcard = &RobotsRules{}
+				}
+				current = append(current, wildcard)
+			case agent != "" && agent == product:
+				if specific == nil {
+					specific = &RobotsRules{}
+				}
+				current = append(current, specific)
+			}
+			continue
+		}
+		inAgents = false
+
+		for _, group := range current {
+			switch key {
+			case "allow":
+				if value != "" {
+					group.rules = append(group.rules, newRobotsRule(value, true))
+				}
+			case "disallow":
+				// An empty Disallow allows everything, so it adds no rule
+				if value != "" {
+					group.rules = append(group.rules, newRobotsRule(value, false))
+				}
+			case "crawl-delay":
+				if seconds, err := strconv.ParseFloat(value, 64); err == nil && seconds > 0 {
+					group.crawlDelay = time.Duration(seconds * float64(time.Second))
+				}
+			}
+		}
+	}
+
+	switch {
+	case specific != nil:
+		return specific
+	case wildcard != nil:
+		return wildcard
+	default:
+		return allowAll
+	}
+}
+
+// Allowed reports whether path (including any query) may be fetched. The longest
+// matching rule wins, and Allow wins a tie.
+func (r *RobotsRules) Allowed(path string) bool {
+	if path == "/robots.txt" {
+		return true
+	}
+
+	allowed, longest := true, -1
+	for _, rule := range r.rules {
+		if !rule.re.MatchString(path) {
+			continue
+		}
+		if len(rule.pattern) > longest || (len(rule.pattern) == longest && rule.allow) {
+			allowed, longest = rule.allow, len(rule.pattern)
+		}
+	}
+	return allowed
+}
+
+// CrawlDelay returns the Crawl-delay directive, or 0 if there wasn't one
+func (r *RobotsRules) CrawlDelay() time.Duration {
+	return r.crawlDelay
+}
+
+// newRobotsRule compiles a robots.txt path pattern, which supports "*" wildcards and a "$" end anchor
+func newRobotsRule(pattern string, allow bool) robotsRule {
+	anchored := strings.HasSuffix(pattern, "$")
+	parts := strings.Split(strings.TrimSuffix(pattern, "$"), "*")
+	for i, part := range parts {
+		parts[i] = regexp.QuoteMeta(part)
+	}
+
+	expr := "^" + strings.Join(parts, ".*")
+	if anchored {
+		expr += "$"
+	}
+	return robotsRule{pattern: pattern, allow: allow, re: regexp.MustCompile(expr)}
+}
+
+// robotsEntry is a cached robots.txt for one host
+type robotsEntry struct {
+	rules     *RobotsRules
+	expiresAt time.Time
+}
+
+// productToken returns the lowercased product token a user agent starts with, e.g. "salttoday" for "SaltToday/2.0"
+func productToken(userAgent string) string {
+	token, _, _ := strings.Cut(strings.TrimSpace(userAgent), "/")
+	if i := strings.IndexFunc(token, unicode.IsSpace); i >= 0 {
+		token = token[:i]
+	}
+	return strings.ToLower(token)
+}
+
+// RobotsCache fetches and caches robots.txt per host
+type RobotsCache struct {
+	fetcher   Fetcher
+	limiter   *HostLimiter
+	userAgent string
+	ttl       time.Duration
+	timeout   time.Duration
+
+	mu      sync.Mutex
+	entries map[string]*robotsEntry
+	loading map[string]*sync.Mutex
+}
+
+// NewRobotsCache creates a cache that fetches robots.txt with fetcher and feeds Crawl-delay into limiter
+func NewRobotsCache(fetcher Fetcher, limiter *HostLimiter, config *ScrapingConfig) *RobotsCache {
+	return &RobotsCache{
+		fetcher:   fetcher,
+		limiter:   limiter,
+		userAgent: config.RobotsUserAgent,
+		ttl:       config.RobotsCacheTTL,
+		timeout:   config.NavigationTimeout,
+		entries:   make(map[string]*robotsEntry),
+		loading:   make(map[string]*sync.Mutex),
+	}
+}
+
+// Rules returns the robots.txt rules for the host serving u, fetching them if the cache is stale.
+// It only fails when ctx ends first, in which case nothing is cached.
+func (c *RobotsCache) Rules(ctx context.Context, u *url.URL) (*RobotsRules, error) {
+	c.mu.Lock()
+	if entry, ok := c.entries[u.Host]; ok && time.Now().Before(entry.expiresAt) {
+		c.mu.Unlock()
+		return entry.rules, nil
+	}
+	// Only one worker fetches a host's robots.txt, the rest wait for it
+	hostLock, ok := c.loading[u.Host]
+	if !ok {
+		hostLock = &sync.Mutex{}
+		c.loading[u.Host] = hostLock
+	}
+	c.mu.Unlock()
+
+	hostLock.Lock()
+	defer hostLock.Unlock()
+
+	c.mu.Lock()
+	if entry, ok := c.entries[u.Host]; ok && time.Now().Before(entry.expiresAt) {
+		c.mu.Unlock()
+		return entry.rules, nil
+	}
+	c.mu.Unlock()
+
+	rules, ttl, err := c.fetch(ctx, u)
+	if err != nil {
+		return nil, err
+	}
+	if delay := rules.CrawlDelay(); delay > 0 && c.limiter != nil {
+		c.limiter.SetCrawlDelay(u.Host, delay)
+	}
+
+	c.mu.Lock()
+	c.entries[u.Host] = &robotsEntry{rules: rules, expiresAt: time.Now().Add(ttl)}
+	c.mu.Unlock()
+	return rules, nil
+}
+
+// fetch downloads and parses robots.txt, returning the rules and how long to cache them. A fetch
+// cut short by ctx says nothing about the host, so it's returned as an error instead.
+func (c *RobotsCache) fetch(ctx context.Context, u *url.URL) (*RobotsRules, time.Duration, error) {
+	robotsURL := u.Scheme + "://" + u.Host + "/robots.txt"
+	logEntry := logger.New(ctx).WithField("url", robotsURL)
+
+	body, err := c.fetcher.Fetch(ctx, robotsURL, c.timeout)
+	if err != nil {
+		switch {
+		case ErrorKindOf(err) == KindCanceled, ctx.Err() != nil:
+			return nil, 0, err
+		case ErrorKindOf(err) == KindNotFound, ErrorKindOf(err) == KindClient:
+			// No robots.txt means no restrictions
+			return allowAll, c.ttl, nil
+		default:
+			// An unreachable robots.txt means we can't know what is allowed
+			logEntry.WithError(err).Warn("Unable to fetch robots.txt, disallowing host for now")
+			return disallowAll, robotsFailureTTL, nil
+		}
+	}
+
+	rules := ParseRobots(body, c.userAgent)
+	logEntry.WithFields(logrus.Fields{
+		"rules":       len(rules.rules),
+		"crawl_delay": rules.CrawlDelay(),
+	}).Info("Loaded robots.txt")
+	return rules, c.ttl, nil
+}
+
+// RobotsFetcher skips URLs that the host's robots.txt disallows
+type RobotsFetcher struct {
+	next   Fetcher
+	robots *RobotsCache
+}
+
+// NewRobotsFetcher wraps next so that disallowed URLs are never fetched
+func NewRobotsFetcher(next Fetcher, robots *RobotsCache) *RobotsFetcher {
+	return &RobotsFetcher{next: next, robots: robots}
+}
+
+// ErrDisallowedByRobots is wrapped by errors for URLs that robots.txt disallows
+var ErrDisallowedByRobots = errors.New("disallowed by robots.txt")
+
+// Fetch fetches the URL if robots.txt allows it
+func (f *RobotsFetcher) Fetch(ctx context.Context, pageURL string, timeout time.Duration) (string, error) {
+	u, err := url.Parse(pageURL)
+	if err != nil {
+		return "", &ScrapingError{Op: "ParseURL", URL: pageURL, Kind: KindParse, Err: err}
+	}
+
+	rules, err := f.robots.Rules(ctx, u)
+	if err != nil {
+		return "", err
+	}
+	if !rules.Allowed(u.RequestURI()) {
+		logger.New(ctx).WithField("url", pageURL).Warn("Skipping URL disallowed by robots.txt")
+		return "", &ScrapingError{Op: "Robots", URL: pageURL, Kind: KindDisallowed, Err: ErrDisallowedByRobots}
+	}
+
+	return f.next.Fetch(ctx, pageURL, timeout)
+}
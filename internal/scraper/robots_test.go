@@ -0,0 +1,89 @@
+package scraper
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testRobots = `
+# Village Media style robots.txt
+User-agent: *
+Disallow: /search
+Disallow: /*?print=
+Allow: /search/help$
+Crawl-delay: 2
+
+User-agent: BadBot
+User-agent: salttoday
+Disallow: /comments/
+Allow: /comments/get
+Crawl-delay: 0.5
+`
+
+func TestParseRobots(t *testing.T) {
+	tests := map[string]struct {
+		userAgent string
+		path      string
+		allowed   bool
+	}{
+		"wildcard group allows articles":   {"otherbot", "/local-news/story-1000001", true},
+		"wildcard group blocks search":     {"otherbot", "/search?q=salt", false},
+		"wildcard pattern":                 {"otherbot", "/local-news/story-1000001?print=1", false},
+		"anchored allow wins":              {"otherbot", "/search/help", true},
+		"anchored allow only matches end":  {"otherbot", "/search/help/more", false},
+		"specific group replaces wildcard": {"salttoday", "/search", true},
+		"longest match wins":               {"salttoday", "/comments/get?ContentId=1", true},
+		"specific disallow":                {"salttoday", "/comments/post", false},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			rules := ParseRobots(testRobots, tc.userAgent)
+			require.Equal(t, tc.allowed, rules.Allowed(tc.path))
+		})
+	}
+
+	require.Equal(t, 2*time.Second, ParseRobots(testRobots, "otherbot").CrawlDelay())
+	require.Equal(t, 500*time.Millisecond, ParseRobots(testRobots, "salttoday").CrawlDelay())
+	require.True(t, ParseRobots("", "salttoday").Allowed("/anything"))
+}
+
+func TestParseRobotsMatchesProductToken(t *testing.T) {
+	robots := "User-agent: bot\nDisallow: /\n\nUser-agent: SaltToday\nDisallow: /private\n"
+
+	// A shorter group name inside our product token isn't a match
+	require.True(t, ParseRobots(robots, "salttoday").Allowed("/local-news"))
+	require.True(t, ParseRobots(robots, "otherbot").Allowed("/local-news"))
+
+	// Case doesn't matter, and neither does a version after the token
+	require.False(t, ParseRobots(robots, "salttoday/2.0").Allowed("/private"))
+	require.False(t, ParseRobots(robots, "SALTTODAY").Allowed("/private"))
+}
+
+func TestRobotsCacheDoesNotCacheCancellation(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("User-agent: *\nDisallow: /private\n"))
+	}))
+	defer server.Close()
+	u, err := url.Parse(server.URL + "/local-news")
+	require.NoError(t, err)
+
+	cache := NewRobotsCache(NewHTTPFetcher(), nil, DefaultConfig())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = cache.Rules(ctx, u)
+	require.Equal(t, KindCanceled, ErrorKindOf(err))
+
+	// The next request fetches robots.txt rather than finding the host disallowed
+	rules, err := cache.Rules(context.Background(), u)
+	require.NoError(t, err)
+	require.True(t, rules.Allowed("/local-news"))
+	require.False(t, rules.Allowed("/private"))
+}
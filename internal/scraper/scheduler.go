@@ -0,0 +1,251 @@
+package scraper
+
+import (
+	"container/heap"
+	"sync"
+	"time"
+
+	"github.com/salt-today/salttoday2/internal/store"
+)
+
+// scrapeInterval is how long to wait between comment scrapes of an article discovered age ago.
+// New articles get the most comments, so they're scraped most often.
+func scrapeInterval(age time.Duration) time.Duration {
+	switch {
+	case age < 24*time.Hour:
+		return 15 * time.Minute
+	case age < 48*time.Hour:
+		return 30 * time.Minute
+	case age < 96*time.Hour:
+		return 60 * time.Minute
+	case age < 120*time.Hour:
+		return 120 * time.Minute
+	default:
+		return 240 * time.Minute
+	}
+}
+
+// nextScrapeTime returns when an article is next due for a comment scrape
+func nextScrapeTime(article *store.Article, now time.Time) time.Time {
+	if article.LastScrapeTime.IsZero() {
+		return now
+	}
+
+	interval := article.ScrapeInterval
+	if interval == 0 {
+		interval = scrapeInterval(now.Sub(article.DiscoveryTime))
+	}
+	return article.LastScrapeTime.Add(interval)
+}
+
+// articleActivity is what one scrape saw on an article
+type articleActivity struct {
+	comments int
+	votes    int
+}
+
+// countActivity tallies the comments and votes scraped from each article
+func countActivity(comments []*store.Comment) map[int]articleActivity {
+	activity := make(map[int]articleActivity)
+	for _, comment := range comments {
+		seen := activity[comment.Article.ID]
+		seen.comments++
+		seen.votes += int(comment.Likes) + int(comment.Dislikes)
+		activity[comment.Article.ID] = seen
+	}
+	return activity
+}
+
+// recordActivity updates articles scraped at now with the comments that were found, adapting
+// their scrape intervals. Busy articles are scraped more often and quiet ones less often.
+func (c *ScrapingConfig) recordActivity(articles []*store.Article, comments []*store.Comment, now time.Time) {
+	activity := countActivity(comments)
+
+	for _, article := range articles {
+		seen := activity[article.ID]
+		firstScrape := article.ScrapeInterval == 0
+		article.LastScrapeTime = now
+
+		if article.State != "" {
+			continue // Removed or closed, so there's no activity to see
+		} else if firstScrape {
+			article.ScrapeInterval = c.clampInterval(scrapeInterval(now.Sub(article.DiscoveryTime)))
+			if seen.comments > 0 {
+				article.LastActivityTime = now
+			}
+		} else if seen.comments < article.CommentCount {
+			// Fewer comments than last time usually means the scrape failed partway, so it
+			// tells us nothing about activity. Leave the article as it was.
+			continue
+		} else {
+			commentGrowth := seen.comments - article.CommentCount
+			voteGrowth := seen.votes - article.VoteCount
+
+			switch {
+			case commentGrowth >= c.BusyCommentGrowth:
+				article.ScrapeInterval = c.clampInterval(article.ScrapeInterval / 2)
+			case commentGrowth > 0 || voteGrowth > 0:
+				// Steady activity keeps the current interval
+			default:
+				article.ScrapeInterval = c.clampInterval(article.ScrapeInterval * 2)
+			}
+			if commentGrowth > 0 || voteGrowth > 0 {
+				article.LastActivityTime = now
+			}
+		}
+
+		article.CommentCount = seen.comments
+		article.VoteCount = seen.votes
+	}
+}
+
+// clampInterval keeps a scrape interval between MinScrapeInterval and MaxScrapeInterval
+func (c *ScrapingConfig) clampInterval(interval time.Duration) time.Duration {
+	if c.MinScrapeInterval > 0 {
+		interval = max(interval, c.MinScrapeInterval)
+	}
+	if c.MaxScrapeInterval > 0 {
+		interval = min(interval, c.MaxScrapeInterval)
+	}
+	return interval
+}
+
+// isRetired reports whether an article has gone without new comments or votes for RetireAfterStable.
+// Articles that never had any activity count from when they were discovered.
+func (c *ScrapingConfig) isRetired(article *store.Article, now time.Time) bool {
+	if c.RetireAfterStable == 0 || article.LastScrapeTime.IsZero() {
+		return false
+	}
+
+	lastActivity := article.LastActivityTime
+	if lastActivity.IsZero() {
+		lastActivity = article.DiscoveryTime
+	}
+	return now.Sub(lastActivity) >= c.RetireAfterStable
+}
+
+// scheduledArticle is an article waiting in the schedule
+type scheduledArticle struct {
+	article *store.Article
+	due     time.Time
+	index   int
+}
+
+// articleQueue is a min-heap of articles ordered by due time
+type articleQueue []*scheduledArticle
+
+func (q articleQueue) Len() int           { return len(q) }
+func (q articleQueue) Less(i, j int) bool { return q[i].due.Before(q[j].due) }
+
+func (q articleQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+
+func (q *articleQueue) Push(x any) {
+	item := x.(*scheduledArticle)
+	item.index = len(*q)
+	*q = append(*q, item)
+}
+
+func (q *articleQueue) Pop() any {
+	old := *q
+	item := old[len(old)-1]
+	old[len(old)-1] = nil
+	*q = old[:len(old)-1]
+	return item
+}
+
+// Schedule is a priority queue of articles keyed by when their comments are next due
+type Schedule struct {
+	mu    sync.Mutex
+	queue articleQueue
+	byID  map[int]*scheduledArticle
+	wake  chan struct{}
+}
+
+// NewSchedule creates an empty schedule
+func NewSchedule() *Schedule {
+	return &Schedule{
+		byID: make(map[int]*scheduledArticle),
+		wake: make(chan struct{}, 1),
+	}
+}
+
+// Schedule adds an article, or moves it if it's already scheduled
+func (s *Schedule) Schedule(article *store.Article, due time.Time) {
+	s.mu.Lock()
+	if item, ok := s.byID[article.ID]; ok {
+		item.article = article
+		item.due = due
+		heap.Fix(&s.queue, item.index)
+	} else {
+		item := &scheduledArticle{article: article, due: due}
+		heap.Push(&s.queue, item)
+		s.byID[article.ID] = item
+	}
+	s.mu.Unlock()
+
+	// Let the dispatcher know, in case this is now the earliest article
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Contains reports whether an article is scheduled
+func (s *Schedule) Contains(articleID int) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.byID[articleID]
+	return ok
+}
+
+// Remove takes an article out of the schedule
+func (s *Schedule) Remove(articleID int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if item, ok := s.byID[articleID]; ok {
+		heap.Remove(&s.queue, item.index)
+		delete(s.byID, articleID)
+	}
+}
+
+// PopDue removes and returns up to limit articles due by now, earliest first. A limit of 0 means no limit.
+func (s *Schedule) PopDue(now time.Time, limit int) []*store.Article {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var due []*store.Article
+	for s.queue.Len() > 0 && !s.queue[0].due.After(now) && (limit == 0 || len(due) < limit) {
+		item := heap.Pop(&s.queue).(*scheduledArticle)
+		delete(s.byID, item.article.ID)
+		due = append(due, item.article)
+	}
+	return due
+}
+
+// NextDue returns when the earliest article is due, or false if the schedule is empty
+func (s *Schedule) NextDue() (time.Time, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.queue.Len() == 0 {
+		return time.Time{}, false
+	}
+	return s.queue[0].due, true
+}
+
+// Len returns the number of scheduled articles
+func (s *Schedule) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.queue.Len()
+}
+
+// Wake is signalled whenever an article is scheduled
+func (s *Schedule) Wake() <-chan struct{} {
+	return s.wake
+}
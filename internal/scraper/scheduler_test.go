@@ -0,0 +1,129 @@
+package scraper
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/salt-today/salttoday2/internal/store"
+)
+
+func TestNextScrapeTime(t *testing.T) {
+	now := time.Now()
+
+	// Never scraped articles are due straight away
+	require.Equal(t, now, nextScrapeTime(&store.Article{DiscoveryTime: now}, now))
+
+	for _, tc := range []struct {
+		age      time.Duration
+		interval time.Duration
+	}{
+		{2 * time.Hour, 15 * time.Minute},
+		{30 * time.Hour, 30 * time.Minute},
+		{72 * time.Hour, 60 * time.Minute},
+		{100 * time.Hour, 120 * time.Minute},
+		{200 * time.Hour, 240 * time.Minute},
+	} {
+		article := &store.Article{DiscoveryTime: now.Add(-tc.age), LastScrapeTime: now}
+		require.Equal(t, now.Add(tc.interval), nextScrapeTime(article, now), tc.age)
+	}
+
+	// Once adapted, the article's own interval wins
+	article := &store.Article{DiscoveryTime: now, LastScrapeTime: now, ScrapeInterval: 5 * time.Minute}
+	require.Equal(t, now.Add(5*time.Minute), nextScrapeTime(article, now))
+}
+
+func TestSchedule(t *testing.T) {
+	now := time.Now()
+	schedule := NewSchedule()
+
+	schedule.Schedule(&store.Article{ID: 1}, now.Add(time.Minute))
+	schedule.Schedule(&store.Article{ID: 2}, now.Add(-time.Minute))
+	schedule.Schedule(&store.Article{ID: 3}, now.Add(-2*time.Minute))
+	schedule.Schedule(&store.Article{ID: 4}, now.Add(time.Hour))
+	require.Equal(t, 4, schedule.Len())
+
+	// Rescheduling moves rather than duplicates
+	schedule.Schedule(&store.Article{ID: 4}, now.Add(-3*time.Minute))
+	require.Equal(t, 4, schedule.Len())
+
+	schedule.Remove(3)
+	require.False(t, schedule.Contains(3))
+
+	due := schedule.PopDue(now, 1)
+	require.Len(t, due, 1)
+	require.Equal(t, 4, due[0].ID)
+
+	due = schedule.PopDue(now, 0)
+	require.Len(t, due, 1)
+	require.Equal(t, 2, due[0].ID)
+
+	next, ok := schedule.NextDue()
+	require.True(t, ok)
+	require.Equal(t, now.Add(time.Minute), next)
+	require.Empty(t, schedule.PopDue(now, 0))
+}
+
+func TestRecordActivity(t *testing.T) {
+	config := DefaultConfig()
+	now := time.Now()
+	article := &store.Article{ID: 1, DiscoveryTime: now.Add(-time.Hour)}
+	commentsOn := func(n int, votes int32) []*store.Comment {
+		var comments []*store.Comment
+		for i := range n {
+			comments = append(comments, &store.Comment{ID: i, Article: store.Article{ID: 1}, Likes: votes})
+		}
+		return comments
+	}
+
+	// The first scrape starts from the age-based interval
+	config.recordActivity([]*store.Article{article}, commentsOn(2, 1), now)
+	require.Equal(t, 15*time.Minute, article.ScrapeInterval)
+	require.Equal(t, now, article.LastActivityTime)
+	require.Equal(t, 2, article.CommentCount)
+	require.Equal(t, 2, article.VoteCount)
+
+	// A burst of comments halves it
+	now = now.Add(article.ScrapeInterval)
+	config.recordActivity([]*store.Article{article}, commentsOn(10, 1), now)
+	require.Equal(t, 7*time.Minute+30*time.Second, article.ScrapeInterval)
+
+	// Only votes changing keeps it steady
+	now = now.Add(article.ScrapeInterval)
+	config.recordActivity([]*store.Article{article}, commentsOn(10, 2), now)
+	require.Equal(t, 7*time.Minute+30*time.Second, article.ScrapeInterval)
+	require.Equal(t, now, article.LastActivityTime)
+	lastActivity := now
+
+	// Nothing changing doubles it, up to the maximum
+	for range 10 {
+		now = now.Add(article.ScrapeInterval)
+		config.recordActivity([]*store.Article{article}, commentsOn(10, 2), now)
+	}
+	require.Equal(t, config.MaxScrapeInterval, article.ScrapeInterval)
+	require.Equal(t, lastActivity, article.LastActivityTime)
+
+	// A scrape that lost comments is ignored
+	config.recordActivity([]*store.Article{article}, nil, now)
+	require.Equal(t, 10, article.CommentCount)
+	require.Equal(t, now, article.LastScrapeTime)
+}
+
+func TestIsRetired(t *testing.T) {
+	config := DefaultConfig()
+	now := time.Now()
+
+	// Never scraped articles aren't retired, however old
+	require.False(t, config.isRetired(&store.Article{DiscoveryTime: now.Add(-100 * time.Hour)}, now))
+
+	// Articles without any activity count from discovery
+	quiet := &store.Article{DiscoveryTime: now.Add(-50 * time.Hour), LastScrapeTime: now}
+	require.True(t, config.isRetired(quiet, now))
+
+	active := &store.Article{DiscoveryTime: now.Add(-50 * time.Hour), LastScrapeTime: now, LastActivityTime: now.Add(-time.Hour)}
+	require.False(t, config.isRetired(active, now))
+
+	config.RetireAfterStable = 0
+	require.False(t, config.isRetired(quiet, now))
+}
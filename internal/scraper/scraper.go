@@ -1,10 +1,12 @@
 package scraper
 
 import (
+	"cmp"
 	"context"
+	"errors"
 	"fmt"
-	"math"
 	"net/url"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
@@ -17,82 +19,245 @@ import (
 	"github.com/salt-today/salttoday2/internal"
 	"github.com/salt-today/salttoday2/internal/logger"
 	"github.com/salt-today/salttoday2/internal/store"
-	"github.com/salt-today/salttoday2/internal/store/rdb"
 )
 
 // Scraper provides high-performance web scraping with browser automation
 type Scraper struct {
-	pw      *playwright.Playwright
-	browser playwright.Browser
-	pool    *BrowserPool
-	config  *ScrapingConfig
+	pw       *playwright.Playwright
+	pool     *BrowserPool
+	config   *ScrapingConfig
+	fetchers map[FetchMode]Fetcher
+	archive  *Archive
+	limiter  *HostLimiter
+	robots   *RobotsCache
+
+	// storage receives what's scraped, nil for scrapers that only fetch
+	storage store.Storage
+
+	// dryRun receives everything that would have been stored, when configured
+	dryRun *dryRunOutput
+
+	// instanceID owns this scraper's article leases
+	instanceID string
+
+	// lostLeases holds the IDs of claimed articles another scraper took over, whose results are dropped
+	lostLeases sync.Map
+
+	// Alerting and the sites quarantined for parser drift, keyed by name
+	alertMu     sync.Mutex
+	notifier    Notifier
+	alerted     map[string]time.Time
+	quarantined map[string]string
 }
 
-// NewScraper creates a new scraper with configuration
-func NewScraper(ctx context.Context, config *ScrapingConfig) (*Scraper, error) {
+// NewScraper creates a new scraper with configuration, storing what it scrapes in storage.
+// storage may be nil for a scraper that only fetches, such as one printing an article's comments.
+func NewScraper(ctx context.Context, config *ScrapingConfig, storage store.Storage) (*Scraper, error) {
 	if config == nil {
 		config = DefaultConfig()
 	}
+	if err := checkSiteParsers(); err != nil {
+		return nil, err
+	}
+	if err := config.checkSites(); err != nil {
+		return nil, err
+	}
 
 	logEntry := logger.New(ctx)
 
-	// Initialize Playwright
-	pw, err := playwright.Run()
-	if err != nil {
-		return nil, &ScrapingError{Op: "InitializePlaywright", Err: err.Error()}
+	s := &Scraper{
+		config:  config,
+		limiter: NewHostLimiter(config),
+		fetchers: map[FetchMode]Fetcher{
+			FetchModeHTTP: NewHTTPFetcher(),
+		},
+		storage:     storage,
+		instanceID:  cmp.Or(config.InstanceID, newInstanceID()),
+		notifier:    LogNotifier{},
+		alerted:     make(map[string]time.Time),
+		quarantined: make(map[string]string),
+	}
+
+	// Only start a browser when at least one site needs it
+	if config.NeedsBrowser() {
+		if err := s.launchBrowser(ctx); err != nil {
+			return nil, err
+		}
+		s.fetchers[FetchModePlaywright] = NewPlaywrightFetcher(s.pool)
 	}
 
-	// Launch browser with optimized settings
-	browser, err := pw.Chromium.Launch(playwright.BrowserTypeLaunchOptions{
-		Headless: playwright.Bool(config.HeadlessMode),
-		Args:     config.BrowserArgs(),
-	})
-	if err != nil {
-		pw.Stop()
-		return nil, &ScrapingError{Op: "LaunchBrowser", Err: err.Error()}
+	// Every fetch shares the same per-host limits and retry policy, and every retry waits its turn
+	for mode, fetcher := range s.fetchers {
+		s.fetchers[mode] = NewRetryingFetcher(NewRateLimitedFetcher(fetcher, s.limiter), config.RetryPolicy())
 	}
 
-	// Create browser pool for efficient context reuse
-	pool := NewBrowserPool(ctx, browser, config)
+	// robots.txt is checked before anything reaches the network
+	if config.RespectRobotsTxt {
+		robotsFetcher := NewRetryingFetcher(NewRateLimitedFetcher(NewHTTPFetcher(), s.limiter), config.RetryPolicy())
+		s.robots = NewRobotsCache(robotsFetcher, s.limiter, config)
+		for mode, fetcher := range s.fetchers {
+			s.fetchers[mode] = NewRobotsFetcher(fetcher, s.robots)
+		}
+	}
+
+	if config.ArchiveMode != ArchiveModeOff {
+		if err := s.openArchive(); err != nil {
+			s.Close()
+			return nil, err
+		}
+	}
+
+	if config.DryRunOutput != "" {
+		out, err := newDryRunOutput(config.DryRunOutput)
+		if err != nil {
+			s.Close()
+			return nil, err
+		}
+		s.dryRun = out
+		if s.storage != nil {
+			s.storage = &dryRunStorage{Storage: s.storage, out: out}
+		}
+	}
+
+	if s.storage != nil {
+		if err := s.loadQuarantines(ctx); err != nil {
+			s.Close()
+			return nil, err
+		}
+	}
 
 	logEntry.WithFields(logrus.Fields{
 		"article_workers": config.MaxArticleWorkers,
 		"comment_workers": config.MaxCommentWorkers,
 		"headless":        config.HeadlessMode,
+		"fetch_mode":      config.defaultFetchMode(),
+		"browser":         s.pool != nil,
+		"archive_mode":    config.ArchiveMode,
 	}).Info("Scraper initialized")
 
-	return &Scraper{
-		pw:      pw,
-		browser: browser,
-		pool:    pool,
-		config:  config,
-	}, nil
+	return s, nil
+}
+
+// launchBrowser starts Playwright and Chromium and creates the browser pool
+func (s *Scraper) launchBrowser(ctx context.Context) error {
+	// Initialize Playwright
+	pw, err := playwright.Run()
+	if err != nil {
+		return &ScrapingError{Op: "InitializePlaywright", Kind: KindBrowser, Err: err}
+	}
+
+	// Launch browser with optimized settings, again whenever it crashes
+	launch := func() (playwright.Browser, error) {
+		return pw.Chromium.Launch(playwright.BrowserTypeLaunchOptions{
+			Headless: playwright.Bool(s.config.HeadlessMode),
+			Args:     s.config.BrowserArgs(),
+		})
+	}
+
+	// Create browser pool for efficient context reuse
+	pool, err := NewBrowserPool(ctx, launch, s.config)
+	if err != nil {
+		pw.Stop()
+		return err
+	}
+
+	s.pw = pw
+	s.pool = pool
+	return nil
+}
+
+// openArchive wraps every fetcher so that pages are recorded to, or replayed from, the archive
+func (s *Scraper) openArchive() error {
+	archive, err := NewArchive(s.config.ArchiveDir)
+	if err != nil {
+		return err
+	}
+
+	switch s.config.ArchiveMode {
+	case ArchiveModeRecord:
+		run := s.config.ArchiveRun
+		if run == "" {
+			run = time.Now().UTC().Format("20060102T150405Z")
+		}
+		err = archive.StartRun(run)
+	case ArchiveModeReplay:
+		if s.config.ArchiveRun != "" {
+			err = archive.ReplayRun(s.config.ArchiveRun)
+		}
+		// Replays are served for every mode, including ones without a live fetcher
+		s.fetchers[FetchModePlaywright] = nil
+	default:
+		err = &ScrapingError{Op: "OpenArchive", Err: fmt.Errorf("unknown archive mode %q", s.config.ArchiveMode)}
+	}
+	if err != nil {
+		return err
+	}
+
+	for mode, fetcher := range s.fetchers {
+		s.fetchers[mode] = NewArchiveFetcher(fetcher, archive, s.config.ArchiveMode)
+	}
+	s.archive = archive
+	return nil
 }
 
 // Close properly shuts down the scraper
 func (s *Scraper) Close() error {
+	s.closeDryRun()
+	if s.archive != nil {
+		s.archive.Close()
+	}
 	if s.pool != nil {
 		s.pool.Close()
 	}
-	if s.browser != nil {
-		s.browser.Close()
-	}
 	if s.pw != nil {
 		return s.pw.Stop()
 	}
 	return nil
 }
 
+// requireStorage returns the scraper's storage, failing for scrapers created without one
+func (s *Scraper) requireStorage() (store.Storage, error) {
+	if s.storage == nil {
+		return nil, &ScrapingError{Op: "RequireStorage", Kind: KindStorage, Err: errors.New("scraper was created without storage")}
+	}
+	return s.storage, nil
+}
+
+// fetcherFor returns the fetcher configured for the site a URL belongs to
+func (s *Scraper) fetcherFor(pageURL string) Fetcher {
+	mode := s.config.FetchModeFor(siteNameForURL(pageURL))
+	if fetcher, ok := s.fetchers[mode]; ok {
+		return fetcher
+	}
+	return s.fetchers[FetchModeHTTP]
+}
+
+// fetchDocument fetches a URL with the site's fetcher and parses it with goquery
+func (s *Scraper) fetchDocument(ctx context.Context, pageURL string, timeout time.Duration) (*goquery.Document, error) {
+	content, err := s.fetcherFor(pageURL).Fetch(ctx, pageURL, timeout)
+	if err != nil {
+		pagesFetched.With(metricSite(pageURL), "error").Inc()
+		return nil, err
+	}
+	pagesFetched.With(metricSite(pageURL), "ok").Inc()
+
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(content))
+	if err != nil {
+		return nil, &ScrapingError{Op: "ParseHTML", URL: pageURL, Kind: KindParse, Err: err}
+	}
+	return doc, nil
+}
+
 // ScrapeAndStoreArticles scrapes articles from all configured sites
 func (s *Scraper) ScrapeAndStoreArticles(ctx context.Context) error {
 	logEntry := logger.New(ctx).WithField("operation", "scrape_articles")
 
-	storage, err := rdb.New(ctx)
+	storage, err := s.requireStorage()
 	if err != nil {
-		return &ScrapingError{Op: "CreateStorage", Err: err.Error()}
+		return err
 	}
 
-	articlesMap, err := s.scrapeArticlesConcurrently(ctx, internal.SitesMap)
+	articlesMap, err := s.scrapeArticlesConcurrently(ctx, s.config.SitesToScrape())
 	if err != nil {
 		return err
 	}
@@ -108,7 +273,7 @@ func (s *Scraper) ScrapeAndStoreArticles(ctx context.Context) error {
 	}
 
 	if err := storage.AddArticles(ctx, articlesToAdd...); err != nil {
-		return &ScrapingError{Op: "StoreArticles", Err: err.Error()}
+		return &ScrapingError{Op: "StoreArticles", Kind: KindStorage, Err: err}
 	}
 
 	logEntry.WithField("articles_stored", len(articlesToAdd)).Info("Articles scraping completed")
@@ -119,9 +284,9 @@ func (s *Scraper) ScrapeAndStoreArticles(ctx context.Context) error {
 func (s *Scraper) ScrapeAndStoreComments(ctx context.Context, daysAgo int, forceScrape bool) error {
 	logEntry := logger.New(ctx).WithField("operation", "scrape_comments")
 
-	storage, err := rdb.New(ctx)
+	storage, err := s.requireStorage()
 	if err != nil {
-		return &ScrapingError{Op: "CreateStorage", Err: err.Error()}
+		return err
 	}
 
 	// Get articles to process
@@ -135,24 +300,63 @@ func (s *Scraper) ScrapeAndStoreComments(ctx context.Context, daysAgo int, force
 		return nil
 	}
 
-	// Scrape comments concurrently
-	comments, users, err := s.scrapeCommentsConcurrently(ctx, articles)
-	if err != nil {
-		return err
+	// Claimed a batch at a time like the daemon, so articles aren't leased long before they're
+	// scraped and other scrapers can take the rest in the meantime
+	batchSize := s.config.MaxScrapeBatch
+	if batchSize <= 0 {
+		batchSize = len(articles)
 	}
-
-	// Store results
-	if err := s.storeCommentsAndUsers(ctx, storage, comments, users, articles); err != nil {
-		return err
+	total := &commentSummary{}
+	var errs []error
+	for batch := range slices.Chunk(articles, batchSize) {
+		summary, err := s.scrapeAndStoreBatch(ctx, storage, batch, forceScrape)
+		if summary == nil {
+			if err != nil {
+				return errors.Join(append(errs, err)...)
+			}
+			continue
+		}
+		if err != nil {
+			errs = append(errs, err) // Some articles failed to store, the later batches still run
+		}
+		total.articles += summary.articles
+		total.comments += summary.comments
+		total.users += summary.users
 	}
 
 	logEntry.WithFields(logrus.Fields{
-		"articles_processed": len(articles),
-		"comments_found":     len(comments),
-		"users_found":        len(users),
+		"articles_processed": total.articles,
+		"comments_found":     total.comments,
+		"users_found":        total.users,
 	}).Info("Comments scraping completed")
 
-	return nil
+	return errors.Join(errs...)
+}
+
+// scrapeAndStoreBatch claims a batch of due articles and scrapes those it gets that are still due,
+// releasing them once they're stored. The summary is nil when nothing was scraped.
+func (s *Scraper) scrapeAndStoreBatch(ctx context.Context, storage store.Storage, batch []*store.Article, forceScrape bool) (*commentSummary, error) {
+	// Other scrapers may be working through the same articles, only take those they haven't
+	claimed, release, err := s.claimArticles(ctx, storage, batch)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	articles := claimed[:0]
+	now := time.Now()
+	for _, article := range claimed {
+		if forceScrape || s.shouldScrapeArticle(article, now) {
+			articles = append(articles, article)
+		}
+	}
+	if len(articles) == 0 {
+		logger.New(ctx).WithField("batch_size", len(batch)).Info("Every article in the batch is taken by another scraper")
+		return nil, nil
+	}
+
+	// Scrape comments concurrently, storing each article as it's done
+	return s.scrapeAndStoreCommentsConcurrently(ctx, storage, articles)
 }
 
 // scrapeArticlesConcurrently scrapes multiple sites concurrently
@@ -204,11 +408,16 @@ func (s *Scraper) articleWorker(ctx context.Context, workerID int, siteChan <-ch
 	for site := range siteChan {
 		startTime := time.Now()
 
-		articles, err := s.scrapeArticlesFromSite(ctx, site.url)
+		articles, err := s.discoverArticlesFromSite(ctx, site.url)
 		if err != nil {
 			workerLogger.WithError(err).WithField("site", site.name).Error("Failed to scrape site")
+			countError(err)
 			continue
 		}
+		if len(articles) == 0 {
+			// A site that loads but links no articles has most likely changed its markup
+			s.alert(ctx, Alert{Site: site.name, Kind: AlertNoArticles, Message: "no articles found, the article selectors may need updating", Time: time.Now()})
+		}
 
 		// Thread-safe update
 		mu.Lock()
@@ -232,71 +441,12 @@ func (s *Scraper) articleWorker(ctx context.Context, workerID int, siteChan <-ch
 
 // scrapeArticlesFromSite scrapes articles from a single site
 func (s *Scraper) scrapeArticlesFromSite(ctx context.Context, siteURL string) (map[int]*store.Article, error) {
-	var articles map[int]*store.Article
-
-	err := s.pool.WithPage(ctx, func(page playwright.Page) error {
-		// Navigate with timeout
-		_, err := page.Goto(siteURL, playwright.PageGotoOptions{
-			Timeout:   playwright.Float(float64(s.config.NavigationTimeout.Milliseconds())),
-			WaitUntil: playwright.WaitUntilStateDomcontentloaded,
-		})
-		if err != nil {
-			return &ScrapingError{Op: "Navigate", URL: siteURL, Err: err.Error()}
-		}
-
-		// Get page content
-		content, err := page.Content()
-		if err != nil {
-			return &ScrapingError{Op: "GetContent", URL: siteURL, Err: err.Error()}
-		}
-
-		// Parse with goquery
-		doc, err := goquery.NewDocumentFromReader(strings.NewReader(content))
-		if err != nil {
-			return &ScrapingError{Op: "ParseHTML", URL: siteURL, Err: err.Error()}
-		}
-
-		articles = s.parseArticlesFromDoc(ctx, doc, siteURL)
-		return nil
-	})
-
+	doc, err := s.fetchDocument(ctx, siteURL, s.config.NavigationTimeout)
 	if err != nil {
 		return nil, err
 	}
 
-	return articles, nil
-}
-
-// parseArticlesFromDoc extracts articles from parsed HTML
-func (s *Scraper) parseArticlesFromDoc(ctx context.Context, doc *goquery.Document, siteURL string) map[int]*store.Article {
-	articles := make(map[int]*store.Article)
-
-	doc.Find("a.section-item").Each(func(i int, sel *goquery.Selection) {
-		href, exists := sel.Attr("href")
-		if !exists || !isArticleUrl(href) || strings.HasPrefix(href, "http") {
-			return // Skip external or invalid URLs
-		}
-
-		title := strings.TrimSpace(sel.Find("div.section-title").First().Text())
-		title = strings.TrimRightFunc(title, func(r rune) bool {
-			return (r >= '0' && r <= '9') || r == ' '
-		})
-
-		articleID := getArticleId(ctx, href)
-		if articleID == 0 {
-			return // Skip invalid article IDs
-		}
-
-		articles[articleID] = &store.Article{
-			ID:             articleID,
-			Title:          title,
-			Url:            siteURL + href,
-			DiscoveryTime:  time.Now(),
-			LastScrapeTime: time.Now(),
-		}
-	})
-
-	return articles
+	return s.parserFor(siteURL).ParseArticles(ctx, doc, siteURL), nil
 }
 
 // Helper method stubs - implement remaining methods following the same patterns
@@ -306,19 +456,15 @@ func (s *Scraper) getArticlesToScrape(ctx context.Context, storage store.Storage
 
 	articlesSince, err := storage.GetRecentlyDiscoveredArticles(ctx, startingTime)
 	if err != nil {
-		return nil, &ScrapingError{Op: "GetRecentArticles", Err: err.Error()}
+		return nil, &ScrapingError{Op: "GetRecentArticles", Kind: KindStorage, Err: err}
 	}
 
-	if forceScrape {
-		return articlesSince, nil
-	}
-
-	// Filter articles based on scraping schedule
+	// Filter articles based on the sites selected and the scraping schedule
 	now := time.Now()
 	filtered := make([]*store.Article, 0, len(articlesSince))
 
 	for _, article := range articlesSince {
-		if s.shouldScrapeArticle(article, now) {
+		if s.config.scrapesURL(article.Url) && (forceScrape || s.shouldScrapeArticle(article, now)) {
 			filtered = append(filtered, article)
 		}
 	}
@@ -327,24 +473,38 @@ func (s *Scraper) getArticlesToScrape(ctx context.Context, storage store.Storage
 }
 
 func (s *Scraper) shouldScrapeArticle(article *store.Article, now time.Time) bool {
-	hoursSinceDiscovery := now.Sub(article.DiscoveryTime).Hours()
-	minsSinceLastScrape := now.Sub(article.LastScrapeTime).Minutes()
-
-	switch {
-	case hoursSinceDiscovery < 24:
-		return minsSinceLastScrape > 15
-	case hoursSinceDiscovery < 48:
-		return minsSinceLastScrape > 30
-	case hoursSinceDiscovery < 96:
-		return minsSinceLastScrape > 60
-	case hoursSinceDiscovery < 120:
-		return minsSinceLastScrape > 120
-	default:
-		return minsSinceLastScrape > 240
-	}
+	return article.State == "" && !s.config.isRetired(article, now) && !nextScrapeTime(article, now).After(now)
 }
 
-func (s *Scraper) scrapeCommentsConcurrently(ctx context.Context, articles []*store.Article) ([]*store.Comment, []*store.User, error) {
+// articleComments is what a comment worker scraped from one article
+type articleComments struct {
+	article  *store.Article
+	comments []*store.Comment
+	users    map[int]string
+	tally    *parseTally
+
+	// Only some of the article's comments were scraped, so missing ones aren't deletions
+	truncated bool
+
+	// The scrape failed, so it says nothing about the article's activity
+	scrapeErr error
+}
+
+// commentSummary totals what a comment scrape stored
+type commentSummary struct {
+	articles    int
+	comments    int
+	users       int
+	failed      map[int]error // Articles that couldn't be stored, by ID
+	quarantined map[int]bool  // Articles not stored because their site's parser drifted
+	leaseLost   map[int]bool  // Articles not stored because another scraper took over their lease
+}
+
+// scrapeAndStoreCommentsConcurrently scrapes the comments of articles with MaxCommentWorkers workers,
+// and a single writer stores each article as soon as its scrape is done. Workers hand results over
+// through a channel holding CommentWriteBuffer articles, so they wait whenever storage falls behind.
+// Articles that couldn't be stored are listed in the summary and reported as a storage error.
+func (s *Scraper) scrapeAndStoreCommentsConcurrently(ctx context.Context, storage store.Storage, articles []*store.Article) (*commentSummary, error) {
 	logEntry := logger.New(ctx).WithField("operation", "concurrent_comments")
 
 	articleChan := make(chan *store.Article, len(articles))
@@ -353,268 +513,203 @@ func (s *Scraper) scrapeCommentsConcurrently(ctx context.Context, articles []*st
 	}
 	close(articleChan)
 
-	var (
-		allComments     []*store.Comment
-		userIDToNameMap = make(map[int]string)
-		mu              sync.Mutex
-		wg              sync.WaitGroup
-	)
-
 	logEntry.WithFields(logrus.Fields{
 		"total_articles": len(articles),
 		"workers":        s.config.MaxCommentWorkers,
+		"write_buffer":   s.config.CommentWriteBuffer,
 	}).Info("Starting concurrent comment scraping")
 
-	// Start workers
+	results := make(chan *articleComments, s.config.CommentWriteBuffer)
+	var wg sync.WaitGroup
 	for workerID := range s.config.MaxCommentWorkers {
 		wg.Add(1)
 		go func(id int) {
 			defer wg.Done()
-			s.commentWorker(ctx, id, articleChan, &allComments, &userIDToNameMap, &mu)
+			s.commentWorker(ctx, id, articleChan, results)
 		}(workerID)
 	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
 
-	wg.Wait()
-
-	// Convert user map to slice
-	users := make([]*store.User, 0, len(userIDToNameMap))
-	for userID, userName := range userIDToNameMap {
-		users = append(users, &store.User{
-			ID:       userID,
-			UserName: userName,
-		})
-	}
+	summary := &commentSummary{failed: make(map[int]error), quarantined: make(map[int]bool), leaseLost: make(map[int]bool)}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.commentWriter(ctx, storage, results, summary)
+	}()
+	<-done
 
 	logEntry.WithFields(logrus.Fields{
-		"comments_found": len(allComments),
-		"users_found":    len(users),
+		"articles_stored": summary.articles,
+		"articles_failed": len(summary.failed),
+		"quarantined":     len(summary.quarantined),
+		"comments_found":  summary.comments,
+		"users_found":     summary.users,
 	}).Info("Comment scraping completed")
 
-	return allComments, users, nil
+	if len(summary.failed) > 0 {
+		errs := make([]error, 0, len(summary.failed))
+		for _, err := range summary.failed {
+			errs = append(errs, err)
+		}
+		return summary, &ScrapingError{Op: "StoreComments", Kind: KindStorage, Err: errors.Join(errs...)}
+	}
+	return summary, nil
 }
 
-// commentWorker processes articles for comment scraping
-func (s *Scraper) commentWorker(ctx context.Context, workerID int, articleChan <-chan *store.Article, allComments *[]*store.Comment, userIDToNameMap *map[int]string, mu *sync.Mutex) {
+// commentWorker scrapes the comments of each article it receives and passes them to the writer
+func (s *Scraper) commentWorker(ctx context.Context, workerID int, articleChan <-chan *store.Article, results chan<- *articleComments) {
 	workerLogger := logger.New(ctx).WithField("worker_id", workerID)
 
 	for article := range articleChan {
 		startTime := time.Now()
+		result := &articleComments{article: article, users: make(map[int]string), tally: newParseTally()}
 
-		localUserMap := make(map[int]string)
-		comments, err := s.ScrapeCommentsFromArticle(ctx, article, localUserMap)
-		if err != nil {
-			workerLogger.WithError(err).WithField("article_id", article.ID).Error("Failed to scrape comments")
-			continue
-		}
+		comments, err := s.ScrapeCommentsFromArticle(withParseTally(ctx, result.tally), article, result.users)
 
-		// Thread-safe update
-		mu.Lock()
-		*allComments = append(*allComments, comments...)
-		for userID, userName := range localUserMap {
-			(*userIDToNameMap)[userID] = userName
+		// Comments cut short by a page limit are still stored
+		if ErrorKindOf(err) == KindTruncated {
+			result.truncated = true
+			err = nil
 		}
-		mu.Unlock()
-
-		workerLogger.WithFields(logrus.Fields{
-			"article_id":     article.ID,
-			"comments_found": len(comments),
-			"duration":       time.Since(startTime),
-		}).Info("Article comment scraping completed")
-	}
-}
-
-// ScrapeCommentsFromArticle scrapes comments from a single article (public method)
-func (s *Scraper) ScrapeCommentsFromArticle(ctx context.Context, article *store.Article, userIDToNameMap map[int]string) ([]*store.Comment, error) {
-	baseUrl, err := getBaseUrl(article.Url)
-	if err != nil {
-		return nil, &ScrapingError{Op: "GetBaseURL", URL: article.Url, Err: err.Error()}
-	}
 
-	var allComments []*store.Comment
-	lastParentId := 0
-	pageNum := 1
-
-	for pageNum <= s.config.MaxCommentPages && lastParentId >= 0 {
-		commentsUrl := fmt.Sprintf("%s/comments/get?Type=Comment&ContentId=%d&TagId=2346&TagType=Content&Sort=Oldest", baseUrl, article.ID)
-		if len(allComments) > 0 && lastParentId > 0 {
-			commentsUrl += fmt.Sprintf("&lastId=%d", lastParentId)
+		// A failed scrape, or comments vanishing, may mean the article was taken down or closed.
+		// An incomplete one got far enough to show the comments are still there.
+		incomplete := ErrorKindOf(err) == KindIncomplete
+		if (err != nil && !incomplete) || (err == nil && len(comments) == 0 && article.CommentCount > 0) {
+			if state := s.checkArticleState(ctx, article); state != "" {
+				workerLogger.WithFields(logrus.Fields{
+					"article_id": article.ID,
+					"state":      state,
+				}).Info("Article is no longer taking comments")
+				article.State = state
+				err = nil
+			}
 		}
-
-		comments, newLastParentId, err := s.scrapeCommentsFromPage(ctx, commentsUrl, article, userIDToNameMap)
 		if err != nil {
-			return nil, err
+			// Still stored, so the failed attempt counts towards the article's scrape time
+			workerLogger.WithError(err).WithField("article_id", article.ID).Error("Failed to scrape comments")
+			countError(err)
+			result.scrapeErr = err
+		} else {
+			result.comments = comments
+			workerLogger.WithFields(logrus.Fields{
+				"article_id":     article.ID,
+				"comments_found": len(comments),
+				"duration":       time.Since(startTime),
+			}).Info("Article comment scraping completed")
 		}
 
-		allComments = append(allComments, comments...)
-		lastParentId = newLastParentId
-
-		if lastParentId == 0 {
-			break // No more comments
-		}
-		pageNum++
+		results <- result
 	}
+}
 
-	return allComments, nil
+// commentWriter stores each article's results until the workers are done, once the parse quality
+// of its site can be judged, dropping those from sites whose parse quality has fallen too far
+func (s *Scraper) commentWriter(ctx context.Context, storage store.Storage, results <-chan *articleComments, summary *commentSummary) {
+	users := make(map[int]struct{})
+	drift := newDriftCheck()
+	for result := range results {
+		ready, rejected := s.checkDrift(ctx, drift, metricSite(result.article.Url), result)
+		s.writeResults(ctx, storage, ready, rejected, summary, users)
+	}
+	ready, rejected := s.flushDrift(ctx, drift)
+	s.writeResults(ctx, storage, ready, rejected, summary, users)
+	summary.users = len(users)
 }
 
-// scrapeCommentsFromPage scrapes comments from a single page
-func (s *Scraper) scrapeCommentsFromPage(ctx context.Context, commentsUrl string, article *store.Article, userIDToNameMap map[int]string) ([]*store.Comment, int, error) {
-	var comments []*store.Comment
-	var lastParentId int
+// writeResults stores the ready results and counts the rejected ones as quarantined
+func (s *Scraper) writeResults(ctx context.Context, storage store.Storage, ready, rejected []*articleComments, summary *commentSummary, users map[int]struct{}) {
+	for _, result := range rejected {
+		summary.quarantined[result.article.ID] = true
+	}
 
-	err := s.pool.WithPage(ctx, func(page playwright.Page) error {
-		// Navigate to comments URL
-		_, err := page.Goto(commentsUrl, playwright.PageGotoOptions{
-			Timeout:   playwright.Float(float64(s.config.PageLoadTimeout.Milliseconds())),
-			WaitUntil: playwright.WaitUntilStateDomcontentloaded,
-		})
-		if err != nil {
-			return &ScrapingError{Op: "NavigateComments", URL: commentsUrl, Err: err.Error()}
+	for _, result := range ready {
+		if s.leaseLost(result.article.ID) {
+			logger.New(ctx).WithField("article_id", result.article.ID).Warn("Lost the article's lease to another scraper, not storing its comments")
+			summary.leaseLost[result.article.ID] = true
+			continue
 		}
 
-		// Get page content
-		content, err := page.Content()
-		if err != nil {
-			return &ScrapingError{Op: "GetCommentsContent", URL: commentsUrl, Err: err.Error()}
+		if err := s.storeArticleComments(ctx, storage, result); err != nil {
+			logger.New(ctx).WithError(err).WithField("article_id", result.article.ID).Error("Failed to store article comments")
+			countError(err)
+			summary.failed[result.article.ID] = err
+			continue
 		}
+		commentsFound.With(metricSite(result.article.Url)).Add(float64(len(result.comments)))
 
-		// Parse with goquery
-		doc, err := goquery.NewDocumentFromReader(strings.NewReader(content))
-		if err != nil {
-			return &ScrapingError{Op: "ParseCommentsHTML", URL: commentsUrl, Err: err.Error()}
+		summary.articles++
+		summary.comments += len(result.comments)
+		for userID := range result.users {
+			users[userID] = struct{}{}
 		}
+	}
+}
 
-		comments, lastParentId = s.parseCommentsFromDoc(ctx, doc, article, userIDToNameMap)
-		return nil
-	})
-
+// checkArticleState asks the article's parser whether it's still up and taking comments. It
+// returns empty when it is, or when that can't be told.
+func (s *Scraper) checkArticleState(ctx context.Context, article *store.Article) string {
+	state, err := s.parserFor(article.Url).ArticleState(ctx, s.fetchDocument, article)
 	if err != nil {
-		return nil, 0, err
+		logger.New(ctx).WithError(err).WithField("article_id", article.ID).Warn("Failed to check article state")
+		return ""
 	}
-
-	return comments, lastParentId, nil
+	return state
 }
 
-// parseCommentsFromDoc extracts comments from parsed HTML using enhanced logic
-func (s *Scraper) parseCommentsFromDoc(ctx context.Context, doc *goquery.Document, article *store.Article, userIDToNameMap map[int]string) ([]*store.Comment, int) {
-	commentDivs := doc.Find("div.comment")
-	return s.getCommentsEnhanced(ctx, commentDivs, article, userIDToNameMap)
+// ScrapeCommentsFromArticle scrapes comments from a single article (public method)
+func (s *Scraper) ScrapeCommentsFromArticle(ctx context.Context, article *store.Article, userIDToNameMap map[int]string) ([]*store.Comment, error) {
+	return s.parserFor(article.Url).ListComments(ctx, s.fetchDocument, article, userIDToNameMap)
 }
 
-// getCommentsEnhanced processes comments using enhanced scraper (no fatal errors, uses Playwright for replies)
-func (s *Scraper) getCommentsEnhanced(ctx context.Context, commentDivs *goquery.Selection, article *store.Article, userIDToNameMap map[int]string) ([]*store.Comment, int) {
-	logEntry := logger.New(ctx).WithField("article_id", article.ID)
-	var comments []*store.Comment
-	lastParentId := 0
-
-	commentDivs.Each(func(i int, commentDiv *goquery.Selection) {
-		numRepliesStr := commentDiv.AttrOr("data-replies", "0")
-		numReplies, err := strconv.Atoi(numRepliesStr)
-		if err != nil {
-			logEntry.WithError(err).Error("Couldn't parse number of replies, assuming 0")
-			numReplies = 0
-		}
+// storeArticleComments stores one article's comments and commenters, then its scrape time and activity.
+// The article is only marked scraped once its comments are safely stored. A failed scrape only
+// records the attempt, leaving the article's activity and interval as they were.
+func (s *Scraper) storeArticleComments(ctx context.Context, storage store.Storage, result *articleComments) error {
+	article := result.article
 
-		if numReplies == 0 {
-			// If comment has no replies, just get the comment itself
-			comment := newCommentFromDiv(ctx, commentDiv, article.ID, userIDToNameMap)
-			comments = append(comments, comment)
-			lastParentId = comment.ID
-		} else { // If the comment has replies, check for a "Load More" button
-			loadMore := commentDiv.Find("button.comments-more")
-
-			// If the button doesn't exist, just get the comments
-			if loadMore.Length() == 0 {
-				// get the parent comment
-				comments = append(comments, newCommentFromDiv(ctx, commentDiv, article.ID, userIDToNameMap))
-
-				// get the replies using enhanced scraper (no fatal errors)
-				replies := s.getRepliesEnhanced(ctx, article, strconv.Itoa(getCommentID(ctx, commentDiv)), userIDToNameMap)
-				comments = append(comments, replies...)
-
-			} else { // If the button exists, get all replies
-				if numReplies > s.config.MaxReplyChain {
-					logEntry.WithField("num_replies", numReplies).Warn("Large reply chain found, may be truncated")
-				}
-				// Use enhanced scraper for replies (no fatal errors)
-				replies := s.getRepliesEnhanced(ctx, article, loadMore.AttrOr("data-parent", ""), userIDToNameMap)
-				comments = append(comments, replies...)
-			}
+	if result.scrapeErr != nil {
+		if err := storage.SetArticleScrapedAt(ctx, time.Now(), article.ID); err != nil {
+			return &ScrapingError{Op: "StoreScrapedAt", URL: article.Url, Kind: KindStorage, Err: err}
 		}
-	})
-
-	return comments, lastParentId
-}
-
-// getRepliesEnhanced gets reply comments using enhanced scraper (no fatal errors, uses Playwright)
-func (s *Scraper) getRepliesEnhanced(ctx context.Context, article *store.Article, parentID string, userIDToNameMap map[int]string) []*store.Comment {
-	logEntry := logger.New(ctx).WithField("article_id", article.ID)
-	baseUrl, err := getBaseUrl(article.Url)
-	if err != nil {
-		logEntry.WithError(err).Error("Failed to get base URL for replies")
 		return nil
 	}
 
-	commentsUrl := fmt.Sprintf("%s/comments/get?ContentId=%d&TagId=2346&TagType=Content&Sort=Oldest&lastId=%22%22&ParentId=%s", baseUrl, article.ID, parentID)
-
-	var comments []*store.Comment
-	err = s.pool.WithPage(ctx, func(page playwright.Page) error {
-		// Navigate to replies URL
-		_, err := page.Goto(commentsUrl, playwright.PageGotoOptions{
-			Timeout:   playwright.Float(float64(s.config.PageLoadTimeout.Milliseconds())),
-			WaitUntil: playwright.WaitUntilStateDomcontentloaded,
-		})
-		if err != nil {
-			return &ScrapingError{Op: "NavigateReplies", URL: commentsUrl, Err: err.Error()}
+	if len(result.comments) > 0 {
+		addComments := storage.AddComments
+		if result.truncated {
+			addComments = storage.UpdateComments
 		}
-
-		// Get page content
-		content, err := page.Content()
-		if err != nil {
-			return &ScrapingError{Op: "GetRepliesContent", URL: commentsUrl, Err: err.Error()}
-		}
-
-		// Parse with goquery
-		doc, err := goquery.NewDocumentFromReader(strings.NewReader(content))
-		if err != nil {
-			return &ScrapingError{Op: "ParseRepliesHTML", URL: commentsUrl, Err: err.Error()}
+		if err := addComments(ctx, result.comments); err != nil {
+			return &ScrapingError{Op: "StoreComments", URL: article.Url, Kind: KindStorage, Err: err}
 		}
-
-		docComments := doc.Find("div.comment")
-		docComments.Each(func(i int, reply *goquery.Selection) {
-			comments = append(comments, newCommentFromDiv(ctx, reply, article.ID, userIDToNameMap))
-		})
-		return nil
-	})
-
-	if err != nil {
-		logEntry.WithError(err).Error("Failed to fetch replies, skipping")
-		return nil // Return empty slice instead of crashing
 	}
 
-	return comments
-}
-
-func (s *Scraper) storeCommentsAndUsers(ctx context.Context, storage store.Storage, comments []*store.Comment, users []*store.User, articles []*store.Article) error {
-	// Store comments and users, update article scrape times
-	if len(comments) > 0 {
-		if err := storage.AddComments(ctx, comments); err != nil {
-			return &ScrapingError{Op: "StoreComments", Err: err.Error()}
+	if len(result.users) > 0 {
+		users := make([]*store.User, 0, len(result.users))
+		for userID, userName := range result.users {
+			users = append(users, &store.User{ID: userID, UserName: userName})
 		}
-	}
-
-	if len(users) > 0 {
 		if err := storage.AddUsers(ctx, users...); err != nil {
-			return &ScrapingError{Op: "StoreUsers", Err: err.Error()}
+			return &ScrapingError{Op: "StoreUsers", URL: article.Url, Kind: KindStorage, Err: err}
 		}
 	}
 
-	// Update scrape times
-	articleIDs := make([]int, len(articles))
-	for i, article := range articles {
-		articleIDs[i] = article.ID
+	// Update the scrape time and the activity that schedules the next scrape
+	s.config.recordActivity([]*store.Article{article}, result.comments, time.Now())
+	if err := storage.SetArticleActivity(ctx, article); err != nil {
+		return &ScrapingError{Op: "StoreActivity", URL: article.Url, Kind: KindStorage, Err: err}
+	}
+
+	// Record articles found to be removed or closed so they're no longer scheduled
+	if article.State != "" {
+		if err := storage.SetArticleState(ctx, article.State, article.ID); err != nil {
+			return &ScrapingError{Op: "StoreArticleState", URL: article.Url, Kind: KindStorage, Err: err}
+		}
 	}
-	storage.SetArticleScrapedAt(ctx, time.Now(), articleIDs...)
 
 	return nil
 }
@@ -647,106 +742,37 @@ func isArticleUrl(href string) bool {
 	return false
 }
 
-func getBaseUrl(urlString string) (string, error) {
-	u, err := url.Parse(urlString)
-	if err != nil {
-		return "", err
-	}
-
-	return fmt.Sprintf("%s://%s", u.Scheme, u.Host), nil
-}
-
-func getContentHelper(s *goquery.Selection) string {
-	return strings.TrimSpace(s.First().Text())
-}
-
-func getCommentID(ctx context.Context, s *goquery.Selection) int {
-	logEntry := logger.New(ctx)
-	idString := s.AttrOr("data-id", "")
-	id, err := strconv.Atoi(idString)
-	if err != nil {
-		logEntry.WithError(err).Error("Couldn't parse comment ID, using 0")
-		return 0
-	}
-	return id
-}
-
-func getUserID(ctx context.Context, s *goquery.Selection) int {
-	profileHref := s.Find("a.comment-un").AttrOr("href", "0")
-	splitProfile := strings.Split(profileHref, "/")
-	idString := splitProfile[len(splitProfile)-1]
-	id, err := strconv.Atoi(idString)
+// siteForURL returns the registered site whose host matches the URL, or nil if none does
+func siteForURL(pageURL string) *internal.Site {
+	u, err := url.Parse(pageURL)
 	if err != nil {
-		logger.New(ctx).WithError(err).Error("Couldn't parse user ID, using 0")
-		return 0
-	}
-	return id
-}
-
-func getUsername(_ context.Context, s *goquery.Selection) string {
-	return getContentHelper(s.Find("a.comment-un"))
-}
-
-func getTimestamp(ctx context.Context, s *goquery.Selection) time.Time {
-	now := time.Now()
-	timeString := s.Find("time").AttrOr("datetime", now.String())
-	commentTime, err := time.Parse(time.RFC3339, timeString)
-	if err != nil {
-		logger.New(ctx).WithError(err).Error("Couldn't parse time, using current time")
-		return now
-	}
-	return commentTime
-}
-
-func getCommentText(_ context.Context, s *goquery.Selection) string {
-	return getContentHelper(s.Find("div.comment-text"))
-}
-
-func getLikes(ctx context.Context, s *goquery.Selection) int32 {
-	likeString := getContentHelper(s.Find("[value=Upvote]"))
-	likes, err := strconv.Atoi(likeString)
-	if err != nil {
-		logger.New(ctx).WithError(err).Error("Unable to get likes")
-		return 0
+		return nil
 	}
 
-	return int32(likes)
-}
-
-func getDislikes(ctx context.Context, s *goquery.Selection) int32 {
-	dislikeString := getContentHelper(s.Find("[value=Downvote]"))
-	dislikes, err := strconv.Atoi(dislikeString)
-	if err != nil {
-		logger.New(ctx).WithError(err).Error("Unable to get dislikes")
-		return 0
+	for _, site := range internal.Sites {
+		siteURL, err := url.Parse(site.URL)
+		if err == nil && siteURL.Host == u.Host {
+			return site
+		}
 	}
-
-	return int32(dislikes)
+	return nil
 }
 
-func newCommentFromDiv(ctx context.Context, div *goquery.Selection, articleID int, userIDToNameMap map[int]string) *store.Comment {
-	comment := &store.Comment{
-		ID:       getCommentID(ctx, div),
-		Article:  store.Article{ID: articleID},
-		User:     store.User{ID: getUserID(ctx, div)},
-		Time:     getTimestamp(ctx, div),
-		Text:     getCommentText(ctx, div),
-		Likes:    getLikes(ctx, div),
-		Dislikes: getDislikes(ctx, div),
+// siteNameForURL returns the name of the registered site serving the URL, or "" if none does
+func siteNameForURL(pageURL string) string {
+	if site := siteForURL(pageURL); site != nil {
+		return site.Name
 	}
-	userIDToNameMap[comment.User.ID] = getUsername(ctx, div)
-	return comment
+	return ""
 }
 
-func getNumberOfCommentApiCalls(ctx context.Context, doc *goquery.Document) int {
-	topLevelCommentCountStr := doc.Find("div#comments").First().AttrOr("data-count", "0")
-	topLevelCommentCount, err := strconv.Atoi(topLevelCommentCountStr)
+func getBaseUrl(urlString string) (string, error) {
+	u, err := url.Parse(urlString)
 	if err != nil {
-		logger.New(ctx).WithError(err).Error("Error converting data-count to int")
-		return 0
+		return "", err
 	}
 
-	return int(math.Ceil(float64(topLevelCommentCount) / 20))
+	return fmt.Sprintf("%s://%s", u.Scheme, u.Host), nil
 }
 
 func getArticleId(ctx context.Context, url string) int {
@@ -0,0 +1,601 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/stretchr/testify/require"
+
+	"github.com/salt-today/salttoday2/internal"
+	"github.com/salt-today/salttoday2/internal/store"
+)
+
+func loadFixture(t *testing.T, site, name string) *goquery.Document {
+	t.Helper()
+
+	body, err := os.ReadFile(filepath.Join("testdata", site, name))
+	require.NoError(t, err)
+
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(string(body)))
+	require.NoError(t, err)
+	return doc
+}
+
+func TestParseArticles(t *testing.T) {
+	parser := NewVillageMediaParser(nil, DefaultConfig())
+	doc := loadFixture(t, "villagemedia", "homepage.html")
+
+	articles := parser.ParseArticles(context.Background(), doc, "https://www.sootoday.com")
+
+	require.Len(t, articles, 3)
+	require.Equal(t, "City council approves new budget", articles[1000001].Title)
+	require.Equal(t, "https://www.sootoday.com/local-news/city-council-approves-new-budget-1000001", articles[1000001].Url)
+	require.Equal(t, "Man charged after downtown crash", articles[1000002].Title)
+	require.Equal(t, "Greyhounds win in overtime", articles[1000003].Title)
+}
+
+func TestParseComments(t *testing.T) {
+	server := newFixtureServer(t, "villagemedia")
+	s := newTestScraper(t, newFakeStorage())
+	article := &store.Article{ID: 1000001, Url: server.URL + "/local-news/city-council-approves-new-budget-1000001"}
+
+	doc := loadFixture(t, "villagemedia", "comments.html")
+	users := make(map[int]string)
+	parser := NewVillageMediaParser(nil, s.config).(*VillageMediaParser)
+	comments, lastParentID, err := parser.parseComments(context.Background(), s.fetchDocument, doc.Find("div.comment"), article, internal.DefaultCommentTagID, users)
+	require.NoError(t, err)
+
+	ids := make([]int, len(comments))
+	for i, comment := range comments {
+		ids[i] = comment.ID
+		require.Equal(t, article.ID, comment.Article.ID)
+	}
+	require.ElementsMatch(t, []int{101, 102, 201, 202, 203, 204, 103, 301, 104}, ids)
+	require.Equal(t, 104, lastParentID)
+	require.Equal(t, 2, server.requestCount("/comments/get"))
+	require.Equal(t, map[int]string{5001: "alice", 5002: "bob", 5003: "carol", 5004: "dave"}, users)
+}
+
+func TestListCommentsFollowsReplies(t *testing.T) {
+	server := newFixtureServer(t, "villagemedia")
+	s := newTestScraper(t, newFakeStorage())
+	article := &store.Article{ID: 1000040, Url: server.URL + "/local-news/bridge-closure-1000040"}
+
+	users := make(map[int]string)
+	parser := NewVillageMediaParser(nil, s.config)
+	comments, err := parser.ListComments(context.Background(), s.fetchDocument, article, users)
+	require.NoError(t, err)
+
+	// A second page of replies, and a reply to a reply
+	ids := make([]int, len(comments))
+	for i, comment := range comments {
+		ids[i] = comment.ID
+	}
+	require.ElementsMatch(t, []int{401, 402, 411, 412, 413, 421}, ids)
+	require.Equal(t, 5, server.requestCount("/comments/get"))
+
+	// Too shallow a limit leaves the chain incomplete rather than partly scraped
+	s.config.MaxReplyChain = 1
+	_, err = parser.ListComments(context.Background(), s.fetchDocument, article, users)
+	require.Equal(t, KindIncomplete, ErrorKindOf(err))
+}
+
+func TestListCommentsChecksCount(t *testing.T) {
+	server := newFixtureServer(t, "villagemedia")
+	article := &store.Article{
+		ID:            1000041,
+		Url:           server.URL + "/local-news/parade-1000041",
+		DiscoveryTime: time.Now().Add(-time.Hour),
+	}
+	storage := newFakeStorage(article)
+	storage.comments[430] = &store.Comment{ID: 430, Article: store.Article{ID: article.ID}}
+	s := newTestScraper(t, storage)
+
+	// The widget counts three top level comments but only two come back
+	_, err := NewVillageMediaParser(nil, s.config).ListComments(context.Background(), s.fetchDocument, article, make(map[int]string))
+	require.Equal(t, KindIncomplete, ErrorKindOf(err))
+
+	// So nothing is stored that would make the missing one look deleted
+	summary, err := s.scrapeAndStoreCommentsConcurrently(context.Background(), storage, []*store.Article{article})
+	require.NoError(t, err)
+	require.Zero(t, summary.comments)
+	require.Equal(t, []int{430}, storage.commentIDs())
+	require.Empty(t, article.State)
+}
+
+func TestListCommentsStopsAtPageLimit(t *testing.T) {
+	server := newFixtureServer(t, "villagemedia")
+	article := &store.Article{
+		ID:            1000001,
+		Url:           server.URL + "/local-news/city-council-approves-new-budget-1000001",
+		DiscoveryTime: time.Now().Add(-time.Hour),
+	}
+	storage := newFakeStorage(article)
+	s := newTestScraper(t, storage)
+	s.config.MaxCommentPages = 1
+
+	// The first page is returned, marked as truncated rather than failed
+	comments, err := NewVillageMediaParser(nil, s.config).ListComments(context.Background(), s.fetchDocument, article, make(map[int]string))
+	require.Equal(t, KindTruncated, ErrorKindOf(err))
+	require.NotEmpty(t, comments)
+
+	// And stored without treating the rest as deleted
+	summary, err := s.scrapeAndStoreCommentsConcurrently(context.Background(), storage, []*store.Article{article})
+	require.NoError(t, err)
+	require.Equal(t, len(comments), summary.comments)
+	require.Equal(t, len(comments), storage.updatedComments)
+}
+
+func TestListCommentsEndingAtPageLimit(t *testing.T) {
+	server := newFixtureServer(t, "villagemedia")
+	s := newTestScraper(t, newFakeStorage())
+	parser := NewVillageMediaParser(nil, s.config)
+
+	// Two pages of comments with a limit of two, found to be the last by the empty page after them
+	s.config.MaxCommentPages = 2
+	article := &store.Article{ID: 1000001, Url: server.URL + "/local-news/city-council-approves-new-budget-1000001"}
+	comments, err := parser.ListComments(context.Background(), s.fetchDocument, article, make(map[int]string))
+	require.NoError(t, err)
+	require.Len(t, comments, 10)
+	require.Equal(t, 3, server.requestsContaining("Type=Comment&ContentId=1000001"))
+
+	// One page holding every comment the article counts needs no page after it
+	s.config.MaxCommentPages = 1
+	article = &store.Article{ID: 1000040, Url: server.URL + "/local-news/library-1000040"}
+	comments, err = parser.ListComments(context.Background(), s.fetchDocument, article, make(map[int]string))
+	require.NoError(t, err)
+	require.NotEmpty(t, comments)
+	require.Equal(t, 1, server.requestsContaining("Type=Comment&ContentId=1000040"))
+}
+
+func TestCommentTagID(t *testing.T) {
+	server := newFixtureServer(t, "villagemedia")
+	s := newTestScraper(t, newFakeStorage())
+	article := &store.Article{ID: 1000050, Url: server.URL + "/local-news/ferry-schedule-1000050"}
+	articlePath := "/local-news/ferry-schedule-1000050"
+
+	// Found on the article page, which every scrape reads for the comment count
+	for range 2 {
+		comments, err := NewVillageMediaParser(nil, s.config).ListComments(context.Background(), s.fetchDocument, article, make(map[int]string))
+		require.NoError(t, err)
+		require.Len(t, comments, 1)
+	}
+	require.Equal(t, 2, server.requestCount(articlePath))
+	require.Equal(t, 4, server.requestsContaining("TagId=9876"))
+
+	// Then cached for the site, for reply chains fetched on their own
+	_, err := NewVillageMediaParser(nil, s.config).FetchReplies(context.Background(), s.fetchDocument, article, "1", make(map[int]string))
+	require.NoError(t, err)
+	require.Equal(t, 2, server.requestCount(articlePath))
+	require.Equal(t, 5, server.requestsContaining("TagId=9876"))
+
+	// The site registry's takes precedence
+	site := &internal.Site{Name: "FixtureToday", TagID: 1234}
+	_, err = NewVillageMediaParser(site, s.config).ListComments(context.Background(), s.fetchDocument, article, make(map[int]string))
+	require.NoError(t, err)
+	require.Equal(t, 3, server.requestCount(articlePath))
+	require.Equal(t, 2, server.requestsContaining("TagId=1234"))
+
+	// Sites whose pages don't say use the default
+	other := newFixtureServer(t, "villagemedia")
+	article = &store.Article{ID: 1000012, Url: other.URL + "/local-news/library-hours-1000012"}
+	_, err = NewVillageMediaParser(nil, s.config).ListComments(context.Background(), s.fetchDocument, article, make(map[int]string))
+	require.NoError(t, err)
+	require.Equal(t, 1, other.requestsContaining(fmt.Sprintf("TagId=%d", internal.DefaultCommentTagID)))
+
+	// Without caching it, so the site's next article page is read again
+	_, err = NewVillageMediaParser(nil, s.config).FetchReplies(context.Background(), s.fetchDocument, article, "1", make(map[int]string))
+	require.NoError(t, err)
+	require.Equal(t, 2, other.requestCount("/local-news/library-hours-1000012"))
+	require.Equal(t, 2, other.requestsContaining(fmt.Sprintf("TagId=%d", internal.DefaultCommentTagID)))
+}
+
+func TestNewCommentFromDiv(t *testing.T) {
+	doc := loadFixture(t, "villagemedia", "comments.html")
+	users := make(map[int]string)
+
+	comment := newCommentFromDiv(context.Background(), doc.Find("div.comment").First(), internal.DefaultSelectors, 42, users)
+
+	require.Equal(t, 101, comment.ID)
+	require.Equal(t, 42, comment.Article.ID)
+	require.Equal(t, 5001, comment.User.ID)
+	require.Equal(t, time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC), comment.Time.UTC())
+	require.Equal(t, "First comment on the budget.", comment.Text)
+	require.Equal(t, int32(12), comment.Likes)
+	require.Equal(t, int32(3), comment.Dislikes)
+	require.Equal(t, "alice", users[5001])
+}
+
+func TestNewCommentFromDivSiteSelectors(t *testing.T) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(`<div class="reply" data-id="7">
+		<a class="author" href="/members/5009">dana</a>
+		<span class="posted" datetime="2024-05-02T08:30:00Z"></span>
+		<p class="body">Markup from another template.</p>
+		<span class="up">4</span><span class="down">1</span>
+	</div>`))
+	require.NoError(t, err)
+
+	site := &internal.Site{Selectors: internal.Selectors{
+		Comment:         "div.reply",
+		CommentUser:     "a.author",
+		CommentTime:     ".posted",
+		CommentText:     "p.body",
+		CommentUpvote:   ".up",
+		CommentDownvote: ".down",
+	}}
+	users := make(map[int]string)
+	comment := newCommentFromDiv(context.Background(), doc.Find("div.reply"), site.SelectorsOrDefault(), 42, users)
+
+	require.Equal(t, 7, comment.ID)
+	require.Equal(t, 5009, comment.User.ID)
+	require.Equal(t, time.Date(2024, 5, 2, 8, 30, 0, 0, time.UTC), comment.Time.UTC())
+	require.Equal(t, "Markup from another template.", comment.Text)
+	require.Equal(t, int32(4), comment.Likes)
+	require.Equal(t, int32(1), comment.Dislikes)
+	require.Equal(t, "dana", users[5009])
+}
+
+func TestGetUserID(t *testing.T) {
+	tests := map[string]struct {
+		html     string
+		expected int
+	}{
+		"profile link": {
+			html:     `<div class="comment"><a class="comment-un" href="/users/profile/5001">alice</a></div>`,
+			expected: 5001,
+		},
+		"non numeric profile": {
+			html:     `<div class="comment"><a class="comment-un" href="/users/profile/alice">alice</a></div>`,
+			expected: 0,
+		},
+		"missing link": {
+			html:     `<div class="comment"><span>anonymous</span></div>`,
+			expected: 0,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			doc, err := goquery.NewDocumentFromReader(strings.NewReader(tc.html))
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, getUserID(context.Background(), doc.Find("div.comment"), internal.DefaultSelectors))
+		})
+	}
+}
+
+func TestGetTimestamp(t *testing.T) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(`<div class="comment"><time datetime="2024-05-01T08:00:00-04:00"></time></div>`))
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC), getTimestamp(context.Background(), doc.Find("div.comment"), internal.DefaultSelectors).UTC())
+
+	// Falls back to the current time when the markup has no usable timestamp
+	doc, err = goquery.NewDocumentFromReader(strings.NewReader(`<div class="comment"><time>yesterday</time></div>`))
+	require.NoError(t, err)
+	before := time.Now()
+	require.WithinRange(t, getTimestamp(context.Background(), doc.Find("div.comment"), internal.DefaultSelectors), before, time.Now())
+}
+
+func TestScrapeArticlesFromSite(t *testing.T) {
+	server := newFixtureServer(t, "villagemedia")
+	s := newTestScraper(t, newFakeStorage())
+
+	articles, err := s.scrapeArticlesFromSite(context.Background(), server.URL)
+	require.NoError(t, err)
+
+	require.Len(t, articles, 3)
+	require.Equal(t, server.URL+"/city-police-beat/man-charged-after-downtown-crash-1000002", articles[1000002].Url)
+}
+
+func TestScrapeAndStoreArticles(t *testing.T) {
+	server := newFixtureServer(t, "villagemedia")
+	withFixtureSite(t, server.URL)
+	storage := newFakeStorage()
+	s := newTestScraper(t, storage)
+
+	require.NoError(t, s.ScrapeAndStoreArticles(context.Background()))
+
+	require.Len(t, storage.articles, 5)
+	require.Equal(t, "City council approves new budget", storage.articles[1000001].Title)
+	require.Equal(t, store.DiscoverySourceFeed, storage.articles[1000011].DiscoverySource)
+}
+
+func TestScraperWithoutStorage(t *testing.T) {
+	s := newTestScraper(t, nil)
+
+	// Only scrapes that store what they find need storage
+	err := s.ScrapeAndStoreComments(context.Background(), 1, true)
+	require.Equal(t, KindStorage, ErrorKindOf(err))
+	err = s.ScrapeAndStoreArticles(context.Background())
+	require.Equal(t, KindStorage, ErrorKindOf(err))
+}
+
+func TestFetchModeFor(t *testing.T) {
+	config := DefaultConfig()
+
+	// Village Media sites are fetched over HTTP unless the registry says they need a browser
+	require.Equal(t, FetchModeHTTP, config.FetchModeFor("TBNewsWatch"))
+	require.Equal(t, FetchModePlaywright, config.FetchModeFor("Sudbury"))
+
+	config.SiteFetchModes["TBNewsWatch"] = FetchModePlaywright
+	require.Equal(t, FetchModePlaywright, config.FetchModeFor("TBNewsWatch"))
+}
+
+func TestSiteSelection(t *testing.T) {
+	server := newFixtureServer(t, "villagemedia")
+	siteName := withFixtureSite(t, server.URL)
+	articles := []*store.Article{
+		{ID: 1000001, Url: server.URL + "/local-news/city-council-approves-new-budget-1000001", DiscoveryTime: time.Now()},
+		{ID: 2000001, Url: "https://www.example.com/local-news/elsewhere-2000001", DiscoveryTime: time.Now()},
+	}
+	storage := newFakeStorage(articles...)
+	s := newTestScraper(t, storage)
+
+	// Every enabled site by default
+	require.Equal(t, map[string]string{siteName: server.URL}, s.config.SitesToScrape())
+
+	s.config.Sites = []string{siteName}
+	due, err := s.getArticlesToScrape(context.Background(), storage, 1, true)
+	require.NoError(t, err)
+	require.Len(t, due, 1)
+	require.Equal(t, 1000001, due[0].ID)
+
+	config := s.config
+	config.Sites = []string{"NotASite"}
+	_, err = NewScraper(context.Background(), config, storage)
+	require.ErrorContains(t, err, "NotASite")
+}
+
+func TestScrapeAndStoreComments(t *testing.T) {
+	server := newFixtureServer(t, "villagemedia")
+	article := &store.Article{
+		ID:            1000001,
+		Title:         "City council approves new budget",
+		Url:           server.URL + "/local-news/city-council-approves-new-budget-1000001",
+		DiscoveryTime: time.Now().Add(-time.Hour),
+	}
+	storage := newFakeStorage(article)
+	s := newTestScraper(t, storage)
+
+	err := s.ScrapeAndStoreComments(context.Background(), 1, true)
+	require.NoError(t, err)
+
+	// Both pages of top-level comments plus every reply chain
+	require.Equal(t, []int{101, 102, 103, 104, 105, 201, 202, 203, 204, 301}, storage.commentIDs())
+	require.Equal(t, 5, server.requestCount("/comments/get"))
+
+	require.Len(t, storage.users, 4)
+	require.Equal(t, "alice", storage.users[5001].UserName)
+	require.Equal(t, "dave", storage.users[5004].UserName)
+
+	reply := storage.comments[202]
+	require.Equal(t, 5001, reply.User.ID)
+	require.Equal(t, int32(2), reply.Likes)
+	require.Equal(t, int32(9), reply.Dislikes)
+
+	require.Contains(t, storage.scrapedAt, article.ID)
+}
+
+func TestScrapeAndStoreCommentsPerArticle(t *testing.T) {
+	server := newFixtureServer(t, "villagemedia")
+	var articles []*store.Article
+	for id := 1000001; id <= 1000003; id++ {
+		articles = append(articles, &store.Article{
+			ID:            id,
+			Url:           fmt.Sprintf("%s/local-news/story-%d", server.URL, id),
+			DiscoveryTime: time.Now().Add(-time.Hour),
+		})
+	}
+	storage := newFakeStorage(articles...)
+	storage.commentsErr = map[int]error{1000002: errors.New("disk full")}
+	s := newTestScraper(t, storage)
+
+	summary, err := s.scrapeAndStoreCommentsConcurrently(context.Background(), storage, articles)
+	require.Equal(t, KindStorage, ErrorKindOf(err))
+
+	// One article failing to store doesn't lose the others
+	require.Equal(t, 2, summary.articles)
+	require.Contains(t, summary.failed, 1000002)
+	require.Contains(t, storage.scrapedAt, 1000001)
+	require.Contains(t, storage.scrapedAt, 1000003)
+	require.NotContains(t, storage.scrapedAt, 1000002)
+}
+
+func TestScrapeAndStoreCommentsClaimsInBatches(t *testing.T) {
+	server := newFixtureServer(t, "villagemedia")
+	var articles []*store.Article
+	for id := 1000001; id <= 1000005; id++ {
+		articles = append(articles, &store.Article{
+			ID:            id,
+			Url:           fmt.Sprintf("%s/local-news/story-%d", server.URL, id),
+			DiscoveryTime: time.Now().Add(-time.Hour),
+		})
+	}
+	storage := newFakeStorage(articles...)
+	s := newTestScraper(t, storage)
+	s.config.MaxScrapeBatch = 2
+
+	require.NoError(t, s.ScrapeAndStoreComments(context.Background(), 1, true))
+
+	// Claimed a batch at a time rather than every due article at once
+	require.Len(t, storage.claims, 3)
+	for i, size := range []int{2, 2, 1} {
+		require.Len(t, storage.claims[i], size)
+	}
+	require.Empty(t, storage.leases)
+	require.Len(t, storage.scrapedAt, 5)
+}
+
+func TestScrapeFailureOnlyRecordsAttempt(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusInternalServerError)
+	}))
+	t.Cleanup(server.Close)
+
+	lastActivity := time.Now().Add(-2 * time.Hour)
+	article := &store.Article{
+		ID:               1000001,
+		Url:              server.URL + "/local-news/story-1000001",
+		DiscoveryTime:    time.Now().Add(-3 * time.Hour),
+		LastScrapeTime:   time.Now().Add(-time.Hour),
+		LastActivityTime: lastActivity,
+		ScrapeInterval:   30 * time.Minute,
+	}
+	storage := newFakeStorage(article)
+	s := newTestScraper(t, storage)
+
+	_, err := s.scrapeAndStoreCommentsConcurrently(context.Background(), storage, []*store.Article{article})
+	require.NoError(t, err)
+
+	// The attempt is recorded, but a quiet article isn't backed off for a scrape that saw nothing
+	require.WithinDuration(t, time.Now(), storage.scrapedAt[article.ID], time.Minute)
+	require.Equal(t, 30*time.Minute, storage.articles[article.ID].ScrapeInterval)
+	require.Equal(t, lastActivity, storage.articles[article.ID].LastActivityTime)
+}
+
+func TestScrapeAndStoreCommentsBackpressure(t *testing.T) {
+	server := newFixtureServer(t, "villagemedia")
+	var articles []*store.Article
+	for id := 1000001; id <= 1000004; id++ {
+		articles = append(articles, &store.Article{
+			ID:            id,
+			Url:           fmt.Sprintf("%s/local-news/story-%d", server.URL, id),
+			DiscoveryTime: time.Now().Add(-time.Hour),
+		})
+	}
+	storage := newFakeStorage(articles...)
+	storage.commentsGate = make(chan struct{})
+	s := newTestScraper(t, storage)
+	s.config.MaxCommentWorkers = 1
+	s.config.CommentWriteBuffer = 0
+	s.config.DriftMinComments = 0 // Every article is judged, and stored, as it arrives
+
+	var err error
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, err = s.scrapeAndStoreCommentsConcurrently(context.Background(), storage, articles)
+	}()
+
+	// While the writer is stuck storing the first article, the worker finishes the second
+	// and then waits rather than scraping ahead. Each article takes 5 comment requests.
+	require.Eventually(t, func() bool { return server.requestCount("/comments/get") == 10 }, 5*time.Second, 10*time.Millisecond)
+	require.Never(t, func() bool { return server.requestCount("/comments/get") > 10 }, 200*time.Millisecond, 10*time.Millisecond)
+
+	close(storage.commentsGate)
+	<-done
+	require.NoError(t, err)
+	require.Equal(t, 20, server.requestCount("/comments/get"))
+	require.Len(t, storage.scrapedAt, 4)
+}
+
+func TestScrapeDetectsArticleState(t *testing.T) {
+	server := newFixtureServer(t, "villagemedia")
+	previouslyScraped := func(id int, slug string) *store.Article {
+		return &store.Article{
+			ID:             id,
+			Url:            fmt.Sprintf("%s/local-news/%s-%d", server.URL, slug, id),
+			DiscoveryTime:  time.Now().Add(-time.Hour),
+			LastScrapeTime: time.Now().Add(-30 * time.Minute),
+			ScrapeInterval: 15 * time.Minute,
+			CommentCount:   3,
+		}
+	}
+	closed := previouslyScraped(1000010, "road-closures-for-the-weekend-parade")
+	removed := previouslyScraped(1000011, "story-that-was-taken-down")
+	active := previouslyScraped(1000012, "library-hours-extended-over-the-holidays")
+	storage := newFakeStorage(closed, removed, active)
+	s := newTestScraper(t, storage)
+
+	err := s.ScrapeAndStoreComments(context.Background(), 1, false)
+	require.NoError(t, err)
+
+	require.Equal(t, store.ArticleStateCommentsClosed, storage.articles[closed.ID].State)
+	require.Equal(t, store.ArticleStateRemoved, storage.articles[removed.ID].State)
+	require.Empty(t, storage.articles[active.ID].State)
+
+	// An empty scrape of a live article isn't taken as its comments disappearing
+	require.Equal(t, 3, storage.articles[active.ID].CommentCount)
+
+	// Removed and closed articles are no longer scheduled
+	later := time.Now().Add(time.Hour)
+	require.False(t, s.shouldScrapeArticle(storage.articles[closed.ID], later))
+	require.False(t, s.shouldScrapeArticle(storage.articles[removed.ID], later))
+	require.True(t, s.shouldScrapeArticle(storage.articles[active.ID], later))
+}
+
+func TestDiscoverArticlesFromSite(t *testing.T) {
+	server := newFixtureServer(t, "villagemedia")
+	s := newTestScraper(t, newFakeStorage())
+
+	articles, err := s.discoverArticlesFromSite(context.Background(), server.URL)
+	require.NoError(t, err)
+
+	sources := make(map[int]string)
+	for id, article := range articles {
+		sources[id] = article.DiscoverySource
+	}
+	require.Equal(t, map[int]string{
+		1000001: store.DiscoverySourceHomepage,
+		1000002: store.DiscoverySourceHomepage,
+		1000003: store.DiscoverySourceHomepage,
+		1000010: store.DiscoverySourceSitemap,
+		1000011: store.DiscoverySourceFeed,
+	}, sources)
+
+	// The homepage listing wins over later sources
+	require.Equal(t, "City council approves new budget", articles[1000001].Title)
+	require.Equal(t, "Water main break on Queen Street", articles[1000010].Title)
+	require.Equal(t, server.URL+"/local-sports/new-arena-opens-1000011", articles[1000011].Url)
+
+	// Sitemaps last modified before SitemapMaxAge are never fetched
+	require.Zero(t, server.requestCount("/sitemap-archive.xml"))
+}
+
+func TestArticleFromLinkDiscoveryTime(t *testing.T) {
+	s := newTestScraper(t, newFakeStorage())
+	link := "https://www.sootoday.com/local-news/water-main-break-on-queen-street-1000010"
+
+	published := time.Now().Add(-48 * time.Hour)
+	article := s.articleFromLink(context.Background(), "https://www.sootoday.com", link, "Water main break", published, store.DiscoverySourceFeed)
+	require.Equal(t, published, article.DiscoveryTime)
+
+	// Unknown and future publication times fall back to now
+	for _, published := range []time.Time{{}, time.Now().Add(time.Hour)} {
+		article = s.articleFromLink(context.Background(), "https://www.sootoday.com", link, "Water main break", published, store.DiscoverySourceFeed)
+		require.WithinDuration(t, time.Now(), article.DiscoveryTime, time.Minute)
+	}
+}
+
+func TestCrawlCategory(t *testing.T) {
+	server := newFixtureServer(t, "villagemedia")
+	s := newTestScraper(t, newFakeStorage())
+
+	var pages []int
+	crawl := CategoryCrawl{Since: time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)}
+	articles, err := s.crawlCategory(context.Background(), server.URL, "/local-news/", crawl, func(page int, found map[int]*store.Article) error {
+		pages = append(pages, page)
+		return nil
+	})
+	require.NoError(t, err)
+
+	ids := make([]int, 0, len(articles))
+	for id, article := range articles {
+		ids = append(ids, id)
+		require.Equal(t, store.DiscoverySourceCategory, article.DiscoverySource)
+	}
+	require.ElementsMatch(t, []int{1000001, 1000020, 1000021}, ids)
+	require.Equal(t, time.Date(2024, 4, 15, 15, 0, 0, 0, time.UTC), articles[1000021].DiscoveryTime.UTC())
+
+	// Page 2 lists an article older than Since, so page 3 is never fetched
+	require.Equal(t, []int{1, 2}, pages)
+	require.Equal(t, 2, server.requestCount("/local-news"))
+}
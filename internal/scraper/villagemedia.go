@@ -0,0 +1,542 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/sirupsen/logrus"
+
+	"github.com/salt-today/salttoday2/internal"
+	"github.com/salt-today/salttoday2/internal/logger"
+	"github.com/salt-today/salttoday2/internal/store"
+)
+
+// VillageMediaParser parses the layout shared by Village Media sites, where comments
+// are loaded in pages of top level comments from the /comments/get widget endpoint
+type VillageMediaParser struct {
+	selectors internal.Selectors
+	tagID     int // Set by the site registry, otherwise discovered from article pages
+	config    *ScrapingConfig
+}
+
+var _ SiteParser = (*VillageMediaParser)(nil)
+
+// discoveredTagIDs caches the comment TagId found on each site's article pages, keyed by base URL
+var discoveredTagIDs sync.Map
+
+// tagIDPattern finds the TagId in the comment widget's URL when the page doesn't give it as an attribute
+var tagIDPattern = regexp.MustCompile(`TagId=(\d+)`)
+
+// NewVillageMediaParser creates a parser using the site's selector overrides and comment TagId
+func NewVillageMediaParser(site *internal.Site, config *ScrapingConfig) SiteParser {
+	p := &VillageMediaParser{
+		selectors: internal.DefaultSelectors,
+		config:    config,
+	}
+	if site != nil {
+		p.selectors = site.SelectorsOrDefault()
+		p.tagID = site.TagID
+	}
+	return p
+}
+
+// ParseArticles extracts articles from parsed HTML
+func (p *VillageMediaParser) ParseArticles(ctx context.Context, doc *goquery.Document, siteURL string) map[int]*store.Article {
+	articles := make(map[int]*store.Article)
+
+	doc.Find(p.selectors.ArticleLink).Each(func(i int, sel *goquery.Selection) {
+		href, exists := sel.Attr("href")
+		if !exists || !isArticleUrl(href) || strings.HasPrefix(href, "http") {
+			return // Skip external or invalid URLs
+		}
+
+		title := strings.TrimSpace(sel.Find(p.selectors.ArticleTitle).First().Text())
+		title = strings.TrimRightFunc(title, func(r rune) bool {
+			return (r >= '0' && r <= '9') || r == ' '
+		})
+
+		articleID := getArticleId(ctx, href)
+		if articleID == 0 {
+			return // Skip invalid article IDs
+		}
+
+		articles[articleID] = &store.Article{
+			ID:              articleID,
+			Title:           title,
+			Url:             siteURL + href,
+			DiscoveryTime:   time.Now(),
+			LastScrapeTime:  time.Now(),
+			DiscoverySource: store.DiscoverySourceHomepage,
+		}
+	})
+
+	return articles
+}
+
+// ParseListingTimes reads the publish time shown next to each article in a listing
+func (p *VillageMediaParser) ParseListingTimes(ctx context.Context, doc *goquery.Document) map[int]time.Time {
+	times := make(map[int]time.Time)
+
+	doc.Find(p.selectors.ArticleLink).Each(func(i int, sel *goquery.Selection) {
+		href, exists := sel.Attr("href")
+		datetime, hasTime := sel.Find(p.selectors.ListingTime).Attr("datetime")
+		if !exists || !hasTime || !isArticleUrl(href) {
+			return
+		}
+		publishedAt, err := time.Parse(time.RFC3339, datetime)
+		if err != nil {
+			return
+		}
+		if articleID := getArticleId(ctx, href); articleID != 0 {
+			times[articleID] = publishedAt
+		}
+	})
+
+	return times
+}
+
+// ListComments scrapes comments from a single article, a page of top level comments at a time.
+// The result is only returned whole: when a reply chain can't be fetched, or fewer top level
+// comments turn up than the article page's data-count, the comments that were found would make
+// the rest look deleted, so the scrape fails as incomplete instead. When MaxCommentPages runs out
+// before the last page, the comments found are returned along with a truncated error.
+func (p *VillageMediaParser) ListComments(ctx context.Context, fetch DocumentFetcher, article *store.Article, userIDToNameMap map[int]string) ([]*store.Comment, error) {
+	logEntry := logger.New(ctx).WithField("article_id", article.ID)
+
+	baseUrl, err := getBaseUrl(article.Url)
+	if err != nil {
+		return nil, &ScrapingError{Op: "GetBaseURL", URL: article.Url, Kind: KindParse, Err: err}
+	}
+
+	// The article page counts the top level comments, and may name the TagId
+	expected, counted := 0, false
+	articleDoc, articleErr := fetch(ctx, article.Url, p.config.PageLoadTimeout)
+	if articleErr != nil {
+		logEntry.WithError(articleErr).Warn("Couldn't fetch the article page, not checking the comment count")
+	} else {
+		expected, counted = p.topLevelCommentCount(ctx, articleDoc)
+	}
+	tagID := p.commentTagIDFromPage(ctx, article, articleDoc, articleErr)
+
+	var allComments []*store.Comment
+	var replyErrs []error
+	topLevel := 0
+	lastParentId := 0
+	exhausted := false
+
+	pageURL := func(lastId int) string {
+		commentsUrl := fmt.Sprintf("%s/comments/get?Type=Comment&ContentId=%d&TagId=%d&TagType=Content&Sort=Oldest", baseUrl, article.ID, tagID)
+		if lastId > 0 {
+			commentsUrl += fmt.Sprintf("&lastId=%d", lastId)
+		}
+		return commentsUrl
+	}
+
+	for pageNum := 1; pageNum <= p.config.MaxCommentPages; pageNum++ {
+		doc, err := fetch(ctx, pageURL(lastParentId), p.config.PageLoadTimeout)
+		if err != nil {
+			return nil, err
+		}
+
+		commentDivs := doc.Find(p.selectors.Comment)
+		comments, newLastParentId, err := p.parseComments(ctx, fetch, commentDivs, article, tagID, userIDToNameMap)
+		allComments = append(allComments, comments...)
+		topLevel += commentDivs.Length()
+		if err != nil {
+			replyErrs = append(replyErrs, err)
+		}
+
+		if newLastParentId == 0 || newLastParentId == lastParentId {
+			exhausted = true // No more comments
+			break
+		}
+		lastParentId = newLastParentId
+	}
+
+	if err := errors.Join(replyErrs...); err != nil {
+		return nil, &ScrapingError{Op: "ListComments", URL: article.Url, Kind: KindIncomplete, Err: err}
+	}
+	// The page limit may have landed on the last page, which the count or one more page tells
+	if !exhausted {
+		exhausted = (counted && topLevel >= expected) || p.emptyCommentPage(ctx, fetch, pageURL(lastParentId))
+	}
+	if !exhausted {
+		logEntry.WithFields(logrus.Fields{
+			"pages":     p.config.MaxCommentPages,
+			"top_level": topLevel,
+			"expected":  expected,
+		}).Warn("Stopped at the comment page limit, returning the comments found so far")
+		return allComments, &ScrapingError{Op: "ListComments", URL: article.Url, Kind: KindTruncated,
+			Err: fmt.Errorf("stopped after %d pages of comments", p.config.MaxCommentPages)}
+	}
+	if counted && topLevel < expected {
+		return nil, &ScrapingError{Op: "ListComments", URL: article.Url, Kind: KindIncomplete,
+			Err: fmt.Errorf("found %d of %d top level comments", topLevel, expected)}
+	}
+
+	return allComments, nil
+}
+
+// emptyCommentPage reports whether a page of top level comments has none, as pages past the last do.
+// A page that can't be fetched isn't taken to be empty.
+func (p *VillageMediaParser) emptyCommentPage(ctx context.Context, fetch DocumentFetcher, commentsUrl string) bool {
+	doc, err := fetch(ctx, commentsUrl, p.config.PageLoadTimeout)
+	if err != nil {
+		logger.New(ctx).WithError(err).Warn("Couldn't fetch the page after the comment page limit")
+		return false
+	}
+	return doc.Find(p.selectors.Comment).Length() == 0
+}
+
+// parseComments processes a page of top level comments, fetching their replies. It returns the
+// last comment's ID to page from, along with any reply chains that couldn't be fetched.
+func (p *VillageMediaParser) parseComments(ctx context.Context, fetch DocumentFetcher, commentDivs *goquery.Selection, article *store.Article, tagID int, userIDToNameMap map[int]string) ([]*store.Comment, int, error) {
+	logEntry := logger.New(ctx).WithField("article_id", article.ID)
+	var comments []*store.Comment
+	var errs []error
+	lastParentId := 0
+
+	commentDivs.Each(func(i int, commentDiv *goquery.Selection) {
+		comment := newCommentFromDiv(ctx, commentDiv, p.selectors, article.ID, userIDToNameMap)
+		comments = append(comments, comment)
+		lastParentId = comment.ID
+
+		numReplies := getNumReplies(ctx, commentDiv)
+		if numReplies == 0 {
+			return
+		}
+
+		replies, err := p.fetchReplies(ctx, fetch, article, tagID, p.replyParentID(commentDiv), numReplies, 1, userIDToNameMap)
+		if err != nil {
+			logEntry.WithError(err).WithField("comment_id", comment.ID).Error("Failed to fetch replies")
+			countError(err)
+			errs = append(errs, err)
+		}
+		comments = append(comments, replies...)
+	})
+
+	return comments, lastParentId, errors.Join(errs...)
+}
+
+// FetchReplies gets every reply to a parent comment, including replies to replies
+func (p *VillageMediaParser) FetchReplies(ctx context.Context, fetch DocumentFetcher, article *store.Article, parentID string, userIDToNameMap map[int]string) ([]*store.Comment, error) {
+	return p.fetchReplies(ctx, fetch, article, p.commentTagID(ctx, fetch, article), parentID, 0, 1, userIDToNameMap)
+}
+
+// fetchReplies pages through the replies to a comment with lastId, as top level comments are,
+// until the expected number have been found or a page has nothing new. With expected 0, it
+// pages until it runs out. Replies that have replies of their own are followed down to
+// MaxReplyChain levels.
+func (p *VillageMediaParser) fetchReplies(ctx context.Context, fetch DocumentFetcher, article *store.Article, tagID int, parentID string, expected, depth int, userIDToNameMap map[int]string) ([]*store.Comment, error) {
+	if depth > p.config.MaxReplyChain {
+		return nil, &ScrapingError{Op: "FetchReplies", URL: article.Url, Kind: KindIncomplete,
+			Err: fmt.Errorf("replies to comment %s are nested more than %d deep", parentID, p.config.MaxReplyChain)}
+	}
+
+	baseUrl, err := getBaseUrl(article.Url)
+	if err != nil {
+		return nil, &ScrapingError{Op: "GetBaseURL", URL: article.Url, Kind: KindParse, Err: err}
+	}
+
+	var comments []*store.Comment
+	var errs []error
+	seen := make(map[int]bool)
+	found := 0
+	lastID := "%22%22"
+
+	for pageNum := 1; pageNum <= p.config.MaxCommentPages; pageNum++ {
+		commentsUrl := fmt.Sprintf("%s/comments/get?ContentId=%d&TagId=%d&TagType=Content&Sort=Oldest&lastId=%s&ParentId=%s", baseUrl, article.ID, tagID, lastID, parentID)
+
+		doc, err := fetch(ctx, commentsUrl, p.config.PageLoadTimeout)
+		if err != nil {
+			return nil, err
+		}
+
+		newReplies := 0
+		doc.Find(p.selectors.Comment).Each(func(i int, reply *goquery.Selection) {
+			// Pages loaded from a "Load More" button start with the parent comment
+			if reply.AttrOr("data-id", "") == parentID {
+				return
+			}
+
+			comment := newCommentFromDiv(ctx, reply, p.selectors, article.ID, userIDToNameMap)
+			if seen[comment.ID] {
+				return
+			}
+			seen[comment.ID] = true
+			comments = append(comments, comment)
+			lastID = strconv.Itoa(comment.ID)
+			newReplies++
+
+			numReplies := getNumReplies(ctx, reply)
+			if numReplies == 0 {
+				return
+			}
+			nested, err := p.fetchReplies(ctx, fetch, article, tagID, p.replyParentID(reply), numReplies, depth+1, userIDToNameMap)
+			if err != nil {
+				errs = append(errs, err)
+			}
+			for _, nestedReply := range nested {
+				if !seen[nestedReply.ID] {
+					seen[nestedReply.ID] = true
+					comments = append(comments, nestedReply)
+				}
+			}
+		})
+		found += newReplies
+
+		if newReplies == 0 || (expected > 0 && found >= expected) {
+			break
+		}
+	}
+
+	return comments, errors.Join(errs...)
+}
+
+// commentTagIDFromPage is commentTagID for an article page that's already been fetched
+func (p *VillageMediaParser) commentTagIDFromPage(ctx context.Context, article *store.Article, doc *goquery.Document, fetchErr error) int {
+	return p.commentTagID(ctx, func(context.Context, string, time.Duration) (*goquery.Document, error) {
+		return doc, fetchErr
+	}, article)
+}
+
+// commentTagID returns the TagId to load the article's comments with: the site registry's if it
+// sets one, otherwise the one discovered from the site's article pages. The default is used
+// when the article page can't be fetched or doesn't give one, but isn't cached, so a later page
+// that does give one is still read.
+func (p *VillageMediaParser) commentTagID(ctx context.Context, fetch DocumentFetcher, article *store.Article) int {
+	if p.tagID != 0 {
+		return p.tagID
+	}
+
+	baseUrl, err := getBaseUrl(article.Url)
+	if err != nil {
+		return internal.DefaultCommentTagID
+	}
+	if tagID, ok := discoveredTagIDs.Load(baseUrl); ok {
+		return tagID.(int)
+	}
+
+	logEntry := logger.New(ctx).WithField("site", baseUrl)
+	doc, err := fetch(ctx, article.Url, p.config.PageLoadTimeout)
+	if err != nil {
+		logEntry.WithError(err).Warn("Couldn't fetch article to find the comment TagId, using the default")
+		return internal.DefaultCommentTagID
+	}
+
+	tagID, ok := p.parseTagID(doc)
+	if !ok {
+		logEntry.Warn("Article page has no comment TagId, using the default")
+		return internal.DefaultCommentTagID
+	}
+	if tagID != internal.DefaultCommentTagID {
+		logEntry.WithField("tag_id", tagID).Info("Site uses its own comment TagId")
+	}
+	discoveredTagIDs.Store(baseUrl, tagID)
+	return tagID
+}
+
+// parseTagID reads the TagId from the comments section, or from the widget URL in the page's scripts
+func (p *VillageMediaParser) parseTagID(doc *goquery.Document) (int, bool) {
+	if attr, ok := doc.Find(p.selectors.CommentsSection).First().Attr("data-tag-id"); ok {
+		if tagID, err := strconv.Atoi(attr); err == nil && tagID > 0 {
+			return tagID, true
+		}
+	}
+
+	html, err := doc.Html()
+	if err != nil {
+		return 0, false
+	}
+	match := tagIDPattern.FindStringSubmatch(html)
+	if match == nil {
+		return 0, false
+	}
+	tagID, err := strconv.Atoi(match[1])
+	return tagID, err == nil && tagID > 0
+}
+
+// replyParentID is the ID to request a comment's replies with, taken from its "Load More"
+// button when it has one
+func (p *VillageMediaParser) replyParentID(commentDiv *goquery.Selection) string {
+	if parentID := commentDiv.Find(p.selectors.CommentsMore).AttrOr("data-parent", ""); parentID != "" {
+		return parentID
+	}
+	return commentDiv.AttrOr("data-id", "")
+}
+
+// topLevelCommentCount reads the number of top level comments the article page says it has
+func (p *VillageMediaParser) topLevelCommentCount(ctx context.Context, doc *goquery.Document) (int, bool) {
+	countStr, ok := doc.Find(p.selectors.CommentsSection).First().Attr("data-count")
+	if !ok {
+		return 0, false
+	}
+	count, err := strconv.Atoi(countStr)
+	if err != nil {
+		logger.New(ctx).WithError(err).Error("Error converting data-count to int")
+		return 0, false
+	}
+	return count, true
+}
+
+// ArticleState fetches the article page, which 404s once the article is taken down and
+// leaves out the comments section once comments are turned off
+func (p *VillageMediaParser) ArticleState(ctx context.Context, fetch DocumentFetcher, article *store.Article) (string, error) {
+	doc, err := fetch(ctx, article.Url, p.config.PageLoadTimeout)
+	if ErrorKindOf(err) == KindNotFound {
+		return store.ArticleStateRemoved, nil
+	} else if err != nil {
+		return "", err
+	}
+
+	if doc.Find(p.selectors.CommentsSection).Length() == 0 {
+		return store.ArticleStateCommentsClosed, nil
+	}
+	return "", nil
+}
+
+// FetchProfile reads the user's join date, avatar and comment count from /users/profile/<id>,
+// the page their comment username links to. Fields missing from the page are left as they are.
+func (p *VillageMediaParser) FetchProfile(ctx context.Context, fetch DocumentFetcher, siteURL string, user *store.User) error {
+	profileURL := fmt.Sprintf("%s/users/profile/%d", siteURL, user.ID)
+	doc, err := fetch(ctx, profileURL, p.config.PageLoadTimeout)
+	if err != nil {
+		return err
+	}
+
+	if joined, ok := doc.Find(p.selectors.ProfileJoined).First().Attr("datetime"); ok {
+		if joinTime, err := time.Parse(time.RFC3339, joined); err == nil {
+			user.JoinTime = joinTime
+		}
+	}
+
+	if src, ok := doc.Find(p.selectors.ProfileAvatar).First().Attr("src"); ok && src != "" {
+		if avatar, err := url.Parse(src); err == nil {
+			base, _ := url.Parse(profileURL)
+			user.AvatarURL = base.ResolveReference(avatar).String()
+		}
+	}
+
+	// Shown as text like "1,204 comments"
+	countText := strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, getContentHelper(doc.Find(p.selectors.ProfileCommentCount)))
+	if count, err := strconv.Atoi(countText); err == nil {
+		user.ProfileCommentCount = count
+	}
+
+	return nil
+}
+
+func getContentHelper(s *goquery.Selection) string {
+	return strings.TrimSpace(s.First().Text())
+}
+
+func getCommentID(ctx context.Context, s *goquery.Selection) int {
+	logEntry := logger.New(ctx)
+	idString := s.AttrOr("data-id", "")
+	id, err := strconv.Atoi(idString)
+	if err != nil {
+		logEntry.WithError(err).Error("Couldn't parse comment ID, using 0")
+		noteFallback(ctx, fieldCommentID)
+		return 0
+	}
+	return id
+}
+
+func getUserID(ctx context.Context, s *goquery.Selection, selectors internal.Selectors) int {
+	profileHref := s.Find(selectors.CommentUser).AttrOr("href", "")
+	splitProfile := strings.Split(profileHref, "/")
+	idString := splitProfile[len(splitProfile)-1]
+	id, err := strconv.Atoi(idString)
+	if err != nil {
+		logger.New(ctx).WithError(err).Error("Couldn't parse user ID, using 0")
+		noteFallback(ctx, fieldUserID)
+		return 0
+	}
+	return id
+}
+
+func getUsername(_ context.Context, s *goquery.Selection, selectors internal.Selectors) string {
+	return getContentHelper(s.Find(selectors.CommentUser))
+}
+
+func getTimestamp(ctx context.Context, s *goquery.Selection, selectors internal.Selectors) time.Time {
+	now := time.Now()
+	timeString := s.Find(selectors.CommentTime).AttrOr("datetime", now.String())
+	commentTime, err := time.Parse(time.RFC3339, timeString)
+	if err != nil {
+		logger.New(ctx).WithError(err).Error("Couldn't parse time, using current time")
+		noteFallback(ctx, fieldTime)
+		return now
+	}
+	return commentTime
+}
+
+func getCommentText(ctx context.Context, s *goquery.Selection, selectors internal.Selectors) string {
+	text := getContentHelper(s.Find(selectors.CommentText))
+	if text == "" {
+		noteFallback(ctx, fieldText)
+	}
+	return text
+}
+
+func getLikes(ctx context.Context, s *goquery.Selection, selectors internal.Selectors) int32 {
+	likeString := getContentHelper(s.Find(selectors.CommentUpvote))
+	likes, err := strconv.Atoi(likeString)
+	if err != nil {
+		logger.New(ctx).WithError(err).Error("Unable to get likes")
+		noteFallback(ctx, fieldLikes)
+		return 0
+	}
+
+	return int32(likes)
+}
+
+func getDislikes(ctx context.Context, s *goquery.Selection, selectors internal.Selectors) int32 {
+	dislikeString := getContentHelper(s.Find(selectors.CommentDownvote))
+	dislikes, err := strconv.Atoi(dislikeString)
+	if err != nil {
+		logger.New(ctx).WithError(err).Error("Unable to get dislikes")
+		noteFallback(ctx, fieldDislikes)
+		return 0
+	}
+
+	return int32(dislikes)
+}
+
+func newCommentFromDiv(ctx context.Context, div *goquery.Selection, selectors internal.Selectors, articleID int, userIDToNameMap map[int]string) *store.Comment {
+	comment := &store.Comment{
+		ID:       getCommentID(ctx, div),
+		Article:  store.Article{ID: articleID},
+		User:     store.User{ID: getUserID(ctx, div, selectors)},
+		Time:     getTimestamp(ctx, div, selectors),
+		Text:     getCommentText(ctx, div, selectors),
+		Likes:    getLikes(ctx, div, selectors),
+		Dislikes: getDislikes(ctx, div, selectors),
+	}
+	userIDToNameMap[comment.User.ID] = getUsername(ctx, div, selectors)
+	noteComment(ctx)
+	return comment
+}
+
+func getNumReplies(ctx context.Context, s *goquery.Selection) int {
+	numReplies, err := strconv.Atoi(s.AttrOr("data-replies", "0"))
+	if err != nil {
+		logger.New(ctx).WithError(err).Error("Couldn't parse number of replies, assuming 0")
+		noteFallback(ctx, fieldReplies)
+		return 0
+	}
+	return numReplies
+}
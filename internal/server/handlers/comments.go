@@ -50,7 +50,7 @@ func (h *Handler) HandleCommentsPage(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	views.Home(queryOpts, comments, getNextCommentsUrl(queryOpts), internal.SitesMapKeys).Render(r.Context(), w)
+	views.Home(queryOpts, comments, getNextCommentsUrl(queryOpts), internal.EnabledSites()).Render(r.Context(), w)
 }
 
 func (h *Handler) HandleComment(w http.ResponseWriter, r *http.Request) {
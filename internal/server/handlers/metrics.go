@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+
+	"github.com/salt-today/salttoday2/internal/metrics"
+)
+
+var requestDuration = metrics.NewHistogramVec("salttoday_http_request_duration_seconds",
+	"Time taken to serve requests, by route pattern, method and status", metrics.DefBuckets, "route", "method", "status")
+
+// Metrics records how long each request took, labelled with the route pattern rather
+// than the path so IDs in URLs don't each get their own series
+func Metrics(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(ww, r)
+
+		route := "unmatched"
+		if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+			route = rctx.RoutePattern()
+		}
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+		requestDuration.With(route, r.Method, strconv.Itoa(status)).Observe(time.Since(start).Seconds())
+	})
+}
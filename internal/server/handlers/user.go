@@ -79,5 +79,5 @@ func (h *Handler) HandleUserPage(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	views.User(users[0], commentOpts, comments, getNextCommentsUrl(commentOpts), internal.SitesMapKeys).Render(r.Context(), w)
+	views.User(users[0], commentOpts, comments, getNextCommentsUrl(commentOpts), internal.EnabledSites()).Render(r.Context(), w)
 }
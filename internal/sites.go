@@ -1,98 +1,235 @@
 package internal
 
-import "sort"
+import (
+	"cmp"
+	_ "embed"
+	"fmt"
+	"net/url"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Used for articles across multiple sites
+const AllSitesName = "all"
+
+// DefaultCommentTagID is the comment widget TagId used when a site neither sets its own nor shows one on its pages
+const DefaultCommentTagID = 2346
+
+// SitesConfigEnv names a sites file to load instead of the built in one
+const SitesConfigEnv = "SITES_CONFIG"
+
+//go:embed sites.yaml
+var defaultSitesConfig []byte
+
+// Site is an entry in the site registry
+type Site struct {
+	Name        string    `yaml:"name"`
+	DisplayName string    `yaml:"display_name"`
+	URL         string    `yaml:"url"`
+	Enabled     bool      `yaml:"enabled"`
+	Region      string    `yaml:"region"`
+	Timezone    string    `yaml:"timezone"`
+	TagID       int       `yaml:"tag_id"`
+	FetchMode   string    `yaml:"fetch_mode"`
+	Parser      string    `yaml:"parser"`
+	Selectors   Selectors `yaml:"selectors"`
+
+	location *time.Location
+}
+
+// Selectors are the CSS selectors used to scrape a site, empty ones fall back to DefaultSelectors
+type Selectors struct {
+	ArticleLink     string `yaml:"article_link"`
+	ArticleTitle    string `yaml:"article_title"`
+	ListingTime     string `yaml:"listing_time"`
+	Comment         string `yaml:"comment"`
+	CommentsMore    string `yaml:"comments_more"`
+	CommentsSection string `yaml:"comments_section"` // Only on article pages still taking comments
+
+	// Within each comment
+	CommentUser     string `yaml:"comment_user"` // Link to the commenter's profile, named for them
+	CommentTime     string `yaml:"comment_time"` // Element with a datetime attribute
+	CommentText     string `yaml:"comment_text"`
+	CommentUpvote   string `yaml:"comment_upvote"`
+	CommentDownvote string `yaml:"comment_downvote"`
+
+	// On commenter profile pages
+	ProfileJoined       string `yaml:"profile_joined"` // Element with a datetime attribute
+	ProfileAvatar       string `yaml:"profile_avatar"` // Image with the avatar as its src
+	ProfileCommentCount string `yaml:"profile_comment_count"`
+}
+
+// DefaultSelectors match the Village Media site template
+var DefaultSelectors = Selectors{
+	ArticleLink:     "a.section-item",
+	ArticleTitle:    "div.section-title",
+	ListingTime:     "time",
+	Comment:         "div.comment",
+	CommentsMore:    "button.comments-more",
+	CommentsSection: "#comments",
+
+	CommentUser:     "a.comment-un",
+	CommentTime:     "time",
+	CommentText:     "div.comment-text",
+	CommentUpvote:   "[value=Upvote]",
+	CommentDownvote: "[value=Downvote]",
+
+	ProfileJoined:       ".profile-joined time",
+	ProfileAvatar:       "img.profile-avatar",
+	ProfileCommentCount: ".profile-comment-count",
+}
+
+var (
+	// Sites is every site in the registry, enabled or not, sorted by name
+	Sites []*Site
+
+	// SitesMap maps each enabled site's name to its URL
+	SitesMap map[string]string
+
+	// SitesMapKeys are the enabled site names, sorted
+	SitesMapKeys []string
+)
 
 func init() {
-	SitesMapKeys = make([]string, 0, len(SitesMap))
-	for key := range SitesMap {
-		SitesMapKeys = append(SitesMapKeys, key)
+	config, source := defaultSitesConfig, "built in sites.yaml"
+	if path := os.Getenv(SitesConfigEnv); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			panic(fmt.Sprintf("unable to read %s: %v", path, err))
+		}
+		config, source = data, path
+	}
+
+	sites, err := ParseSites(config)
+	if err != nil {
+		panic(fmt.Sprintf("invalid sites in %s: %v", source, err))
 	}
-	sort.Strings(SitesMapKeys)
+	SetSites(sites)
 }
 
-var SitesMapKeys []string
+// ParseSites parses and validates a YAML (or JSON) sites file
+func ParseSites(data []byte) ([]*Site, error) {
+	var config struct {
+		Sites []*Site `yaml:"sites"`
+	}
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, err
+	}
 
-// Used for articles across multiple sites
-const AllSitesName = "all"
+	seen := make(map[string]bool)
+	for _, site := range config.Sites {
+		if err := site.validate(); err != nil {
+			return nil, err
+		}
+		if seen[site.Name] {
+			return nil, fmt.Errorf("site %s is defined more than once", site.Name)
+		}
+		seen[site.Name] = true
+	}
+	return config.Sites, nil
+}
 
-// TODO uncomment sites when we're ready, no need to hammer all of them yet
-// List taken from here: villagemedia.ca/sites/
-var SitesMap = map[string]string{
-	"TBNewsWatch": "https://www.tbnewswatch.com",
-	// "BarrieToday":   "https://www.barrietoday.com",
-	"BayToday": "https://www.baytoday.ca",
-	// "BradfordToday": "https://www.bradfordtoday.ca",
-	// "BurlingtonToday":  "https://burlingtontoday.com",
-	// "CambridgeToday":   "https://www.cambridgetoday.ca",
-	// "CollingwoodToday": "https://www.collingwoodtoday.ca",
-	// "ElliotLakeToday":  "https://www.elliotlaketoday.com",
-	// "EloraFergusToday": "https://www.elorafergustoday.com",
-	"GuelphToday": "https://www.guelphtoday.com",
-	// "HaltonHillsToday": "https://www.haltonhillstoday.ca",
-	// "innisfilToday":           "https://www.innisfiltoday.ca",
-	// "MidlandToday":            "https://midlandtoday.ca",
-	// "NewMarketToday": "https://www.newmarkettoday.ca",
-	// "NotLocal":                "https://www.notllocal.com",
-	// "NorthernOntarioBusiness": "https://www.northernontariobusiness.com",
-	"OrilliaMatters": "https://www.orilliamatters.com",
-	// "PelhamToday":             "https://www.pelhamtoday.ca",
-	"SooToday": "https://www.sootoday.com",
-	// "StratfordToday": "https://www.stratfordtoday.ca",
-	// "Sudbury":         "https://sudbury.com", // This requires Javascript to be enabled. The jerks.
-	// "ThoroldToday":            "https://www.thoroldtoday.ca",
-	// "TimminsToday": "https://www.timminstoday.com",
-	// "AlimoshoToday": "https://www.alimoshotoday.com",
-	// "BroomfieldLeader":        "https://www.broomfieldleader.com",
-	// "Lasutoday":      "https://www.lasutoday.com",
-	// "LongmontLeader": "https://www.longmontleader.com",
-	// "Sooleader":              "https://www.sooleader.com",
-	// "BkReader":               "https://www.bkreader.com",            // verify this works, doesn't look like others
-	// "CharlestonCityPaper": "https://www.charlestoncitypaper.com", // verify this works, doesn't look like others
-	// "NowNewsWatch":           "https://www.nwonewswatch.com",        // I think this one is just articles from the other sites in the region
-	// "SNNewsWatch": "https://www.snnewswatch.com", // I think this one is just articles from the other sites in the region
-	// "ChulavisToday":          "https://chulavistatoday.com",
-	// "LivermoreVine":          "https://www.livermorevine.com", // verify this works, doesn't look like others
-	// "RWCPulse":               "https://www.rwcpulse.com",      // verify this works, doesn't look like others
-	// "AlaskaHighwayNews":      "https://www.alaskahighwaynews.ca",
-	// "BowenIslandUnderCurrent": "https://www.bowenislandundercurrent.com",
-	// "BurnabyNow":             "https://www.burnabynow.com",
-	// "CoastReporter": "https://www.coastreporter.net",
-	// "DawsonCreekMirror":       "https://www.dawsoncreekmirror.ca",
-	// "DeltaOptimist":       "https://www.delta-optimist.com",
-	// "TheReminder": "https://www.thereminder.ca",
-	// "KamloopsThisWeek": "https://www.kamloopsthisweek.com",
-	// "MooseJawToday": "https://www.moosejawtoday.com",
-	// "NewwestRecord":       "https://newwestrecord.ca",
-	// "NSNews":             "https://www.nsnews.com",
-	// "PiqeueNewsMagazine": "https://www.piquenewsmagazine.com",
-	// "PRPeak":             "https://www.prpeak.com",
-	// "Praireag":            "https://www.prairieag.com",
-	"PrinceGeorgeCitizen": "https://www.princegeorgecitizen.com",
-	// "RichmondNews":        "https://www.richmond-news.com",
-	// "SaskToday":           "https://www.sasktoday.ca", // verify this works, doesn't look like others
-	// "SquamishChief": "https://www.squamishchief.com",
-	// "ThompsonCitizen":     "https://www.thompsoncitizen.net",
-	// "TricityNews":        "https://www.tricitynews.com",
-	// "VancouverIsAwesome": "https://www.vancouverisawesome.com",
-	// "TimesColonist":      "https://www.timescolonist.com",
-	// "EmpireAdvance":      "https://www.empireadvance.ca",
-	// "WesternInvestor":     "https://www.westerninvestor.com",
-	// 	"AirdrieToday":      "https://www.airdrietoday.com",
-	// "AlbertaPrimeTimes": "https://www.albertaprimetimes.com",
-	// "CochraneToday":     "https://www.cochranetoday.ca",
-	// "LakelandToday":     "https://www.lakelandtoday.ca",
-	// "MountainviewToday": "https://www.mountainviewtoday.ca",
-	"OkotoksToday": "https://www.okotokstoday.ca",
-	// "RMOutlook":           "https://www.rmoutlook.com",
-	// "StalbertGazette":     "https://www.stalbertgazette.com",
-	// "TownAndCountryToday": "https://www.townandcountrytoday.com",
-	// "GriceConnect":        "https://www.griceconnect.com",
-	// "LocalProfile": "https://www.localprofile.com", // verify this works, doesn't look like others
-	// "GazetteLeader":       "https://www.gazetteleader.com",
-	// "QueenCreekSunTimes": "https://www.queencreeksuntimes.com",
-	// "RoughDraftAtlanta":  "https://www.roughdraftatlanta.com", // verify this works, doesn't look like others
-	// "HalifaxCityNews":     "https://www.halifax.citynews.ca",   // verify this works, doesn't look like others
-	// "KitchenerCityNews":   "https://www.kitchener.citynews.ca", // verify this works, doesn't look like others
-	// "OttawaCityNews":          "https://www.ottawa.citynews.ca",      // verify this works, doesn't look like others
-	// "WashingtonCityPaper": "https://www.washingtoncitypaper.com", // verify this works, doesn't look like others
+// validate checks a site's fields and fills in defaults
+func (s *Site) validate() error {
+	if s.Name == "" {
+		return fmt.Errorf("site with url %q has no name", s.URL)
+	}
+	if s.Name == AllSitesName {
+		return fmt.Errorf("site name %q is reserved", AllSitesName)
+	}
+
+	u, err := url.Parse(s.URL)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return fmt.Errorf("site %s has an invalid url %q", s.Name, s.URL)
+	}
+	s.URL = strings.TrimSuffix(s.URL, "/")
+
+	if s.DisplayName == "" {
+		s.DisplayName = s.Name
+	}
+
+	s.location = time.Local
+	if s.Timezone != "" {
+		if s.location, err = time.LoadLocation(s.Timezone); err != nil {
+			return fmt.Errorf("site %s has an invalid timezone: %w", s.Name, err)
+		}
+	}
+
+	switch s.FetchMode {
+	case "", "http", "playwright":
+	default:
+		return fmt.Errorf("site %s has an unknown fetch mode %q", s.Name, s.FetchMode)
+	}
+	return nil
+}
+
+// SetSites replaces the registry and everything derived from it
+func SetSites(sites []*Site) {
+	Sites = slices.Clone(sites)
+	sort.Slice(Sites, func(i, j int) bool { return Sites[i].Name < Sites[j].Name })
+
+	SitesMap = make(map[string]string)
+	SitesMapKeys = make([]string, 0)
+	for _, site := range Sites {
+		if site.Enabled {
+			SitesMap[site.Name] = site.URL
+			SitesMapKeys = append(SitesMapKeys, site.Name)
+		}
+	}
+}
+
+// EnabledSites returns the enabled sites, sorted by name
+func EnabledSites() []*Site {
+	sites := make([]*Site, 0, len(SitesMapKeys))
+	for _, site := range Sites {
+		if site.Enabled {
+			sites = append(sites, site)
+		}
+	}
+	return sites
+}
+
+// SiteByName returns the named site, enabled or not
+func SiteByName(name string) (*Site, bool) {
+	for _, site := range Sites {
+		if site.Name == name {
+			return site, true
+		}
+	}
+	return nil, false
+}
+
+// Location returns the site's time zone
+func (s *Site) Location() *time.Location {
+	if s.location == nil {
+		return time.Local
+	}
+	return s.location
+}
+
+// SelectorsOrDefault returns the site's CSS selectors, with overrides applied over the defaults
+func (s *Site) SelectorsOrDefault() Selectors {
+	return Selectors{
+		ArticleLink:     cmp.Or(s.Selectors.ArticleLink, DefaultSelectors.ArticleLink),
+		ArticleTitle:    cmp.Or(s.Selectors.ArticleTitle, DefaultSelectors.ArticleTitle),
+		ListingTime:     cmp.Or(s.Selectors.ListingTime, DefaultSelectors.ListingTime),
+		Comment:         cmp.Or(s.Selectors.Comment, DefaultSelectors.Comment),
+		CommentsMore:    cmp.Or(s.Selectors.CommentsMore, DefaultSelectors.CommentsMore),
+		CommentsSection: cmp.Or(s.Selectors.CommentsSection, DefaultSelectors.CommentsSection),
+
+		CommentUser:     cmp.Or(s.Selectors.CommentUser, DefaultSelectors.CommentUser),
+		CommentTime:     cmp.Or(s.Selectors.CommentTime, DefaultSelectors.CommentTime),
+		CommentText:     cmp.Or(s.Selectors.CommentText, DefaultSelectors.CommentText),
+		CommentUpvote:   cmp.Or(s.Selectors.CommentUpvote, DefaultSelectors.CommentUpvote),
+		CommentDownvote: cmp.Or(s.Selectors.CommentDownvote, DefaultSelectors.CommentDownvote),
+
+		ProfileJoined:       cmp.Or(s.Selectors.ProfileJoined, DefaultSelectors.ProfileJoined),
+		ProfileAvatar:       cmp.Or(s.Selectors.ProfileAvatar, DefaultSelectors.ProfileAvatar),
+		ProfileCommentCount: cmp.Or(s.Selectors.ProfileCommentCount, DefaultSelectors.ProfileCommentCount),
+	}
 }
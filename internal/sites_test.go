@@ -0,0 +1,60 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuiltInSites(t *testing.T) {
+	sites, err := ParseSites(defaultSitesConfig)
+	require.NoError(t, err)
+	require.NotEmpty(t, sites)
+
+	require.Contains(t, SitesMapKeys, "SooToday")
+	require.Equal(t, "https://www.sootoday.com", SitesMap["SooToday"])
+	require.NotContains(t, SitesMap, "BarrieToday") // Registered but not enabled
+
+	site, ok := SiteByName("BarrieToday")
+	require.True(t, ok)
+	require.Equal(t, "America/Toronto", site.Location().String())
+	require.Zero(t, site.TagID) // Discovered from its article pages
+}
+
+func TestParseSites(t *testing.T) {
+	sites, err := ParseSites([]byte(`
+sites:
+  - name: ExampleToday
+    url: https://www.exampletoday.ca/
+    enabled: true
+    tag_id: 1234
+    fetch_mode: http
+    selectors:
+      comment: div.reply
+`))
+	require.NoError(t, err)
+	require.Len(t, sites, 1)
+
+	site := sites[0]
+	require.Equal(t, "ExampleToday", site.DisplayName)
+	require.Equal(t, "https://www.exampletoday.ca", site.URL)
+	require.Equal(t, 1234, site.TagID)
+	require.Equal(t, "div.reply", site.SelectorsOrDefault().Comment)
+	require.Equal(t, DefaultSelectors.ArticleLink, site.SelectorsOrDefault().ArticleLink)
+
+	// JSON works too, since it's valid YAML
+	_, err = ParseSites([]byte(`{"sites": [{"name": "ExampleToday", "url": "https://www.exampletoday.ca"}]}`))
+	require.NoError(t, err)
+
+	for _, invalid := range []string{
+		`sites: [{url: "https://www.exampletoday.ca"}]`,
+		`sites: [{name: ExampleToday, url: "not a url"}]`,
+		`sites: [{name: ExampleToday, url: "https://www.exampletoday.ca", timezone: Mars/Olympus}]`,
+		`sites: [{name: ExampleToday, url: "https://www.exampletoday.ca", fetch_mode: carrier-pigeon}]`,
+		`sites: [{name: all, url: "https://www.exampletoday.ca"}]`,
+		`sites: [{name: ExampleToday, url: "https://a.ca"}, {name: ExampleToday, url: "https://b.ca"}]`,
+	} {
+		_, err := ParseSites([]byte(invalid))
+		require.Error(t, err, invalid)
+	}
+}
@@ -0,0 +1,7 @@
+-- +migrate Up
+
+ALTER TABLE Articles ADD COLUMN DiscoverySource VARCHAR(16);
+
+-- +migrate Down
+
+ALTER TABLE Articles DROP COLUMN DiscoverySource;
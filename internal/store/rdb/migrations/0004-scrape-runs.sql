@@ -0,0 +1,22 @@
+-- +migrate Up
+
+CREATE TABLE IF NOT EXISTS ScrapeRuns (
+    ID BIGINT NOT NULL AUTO_INCREMENT,
+    Kind VARCHAR(16) NOT NULL,
+    SiteName VARCHAR(255) NOT NULL,
+    Params VARCHAR(255) NOT NULL,
+    Status VARCHAR(16) NOT NULL,
+    Checkpoint VARCHAR(255) NOT NULL DEFAULT(''),
+    ArticlesFound INT NOT NULL DEFAULT(0),
+    CommentsFound INT NOT NULL DEFAULT(0),
+    Error TEXT,
+    StartTime DATETIME NOT NULL,
+    UpdateTime DATETIME NOT NULL,
+    FinishTime DATETIME,
+    PRIMARY KEY (ID),
+    INDEX kind_site (Kind, SiteName)
+);
+
+-- +migrate Down
+
+DROP TABLE ScrapeRuns;
@@ -0,0 +1,13 @@
+-- +migrate Up
+
+ALTER TABLE Articles ADD COLUMN CommentCount INT NOT NULL DEFAULT 0;
+ALTER TABLE Articles ADD COLUMN VoteCount INT NOT NULL DEFAULT 0;
+ALTER TABLE Articles ADD COLUMN ScrapeIntervalSeconds INT NOT NULL DEFAULT 0;
+ALTER TABLE Articles ADD COLUMN LastActivityTime DATETIME;
+
+-- +migrate Down
+
+ALTER TABLE Articles DROP COLUMN LastActivityTime;
+ALTER TABLE Articles DROP COLUMN ScrapeIntervalSeconds;
+ALTER TABLE Articles DROP COLUMN VoteCount;
+ALTER TABLE Articles DROP COLUMN CommentCount;
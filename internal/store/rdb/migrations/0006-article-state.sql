@@ -0,0 +1,7 @@
+-- +migrate Up
+
+ALTER TABLE Articles ADD COLUMN State VARCHAR(16) NOT NULL DEFAULT '';
+
+-- +migrate Down
+
+ALTER TABLE Articles DROP COLUMN State;
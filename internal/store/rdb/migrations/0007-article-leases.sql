@@ -0,0 +1,13 @@
+-- +migrate Up
+
+CREATE TABLE IF NOT EXISTS ArticleLeases (
+    ArticleID INT NOT NULL,
+    Owner VARCHAR(128) NOT NULL,
+    ExpiryTime DATETIME NOT NULL,
+    PRIMARY KEY (ArticleID),
+    INDEX owner (Owner)
+);
+
+-- +migrate Down
+
+DROP TABLE ArticleLeases;
@@ -0,0 +1,15 @@
+-- +migrate Up
+
+ALTER TABLE Users ADD COLUMN JoinTime DATETIME;
+ALTER TABLE Users ADD COLUMN AvatarURL VARCHAR(512) NOT NULL DEFAULT '';
+ALTER TABLE Users ADD COLUMN ProfileCommentCount INT NOT NULL DEFAULT 0;
+ALTER TABLE Users ADD COLUMN ProfileScrapeTime DATETIME;
+CREATE INDEX profile_scrape_time ON Users (ProfileScrapeTime);
+
+-- +migrate Down
+
+DROP INDEX profile_scrape_time ON Users;
+ALTER TABLE Users DROP COLUMN ProfileScrapeTime;
+ALTER TABLE Users DROP COLUMN ProfileCommentCount;
+ALTER TABLE Users DROP COLUMN AvatarURL;
+ALTER TABLE Users DROP COLUMN JoinTime;
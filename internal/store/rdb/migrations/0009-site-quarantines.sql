@@ -0,0 +1,12 @@
+-- +migrate Up
+
+CREATE TABLE IF NOT EXISTS SiteQuarantines (
+    SiteName VARCHAR(128) NOT NULL,
+    Reason TEXT NOT NULL,
+    QuarantineTime DATETIME NOT NULL,
+    PRIMARY KEY (SiteName)
+);
+
+-- +migrate Down
+
+DROP TABLE SiteQuarantines;
@@ -5,6 +5,10 @@ const (
 	CommentsTable = "Comments"
 	UsersTable    = "Users"
 	ArticlesTable = "Articles"
+	RunsTable     = "ScrapeRuns"
+	LeasesTable   = "ArticleLeases"
+
+	QuarantinesTable = "SiteQuarantines"
 )
 
 // Columns
@@ -28,18 +32,41 @@ const (
 	CommentControverstyID              = CommentControverstyView + "." + "ID"
 	CommentControverstyWeightedEntropy = CommentControverstyView + "." + "WeightedEntropy"
 
-	ArticlesID             = ArticlesTable + "." + "ID"
-	ArticlesSiteName       = ArticlesTable + "." + SiteNameSuffix
-	ArticlesUrl            = ArticlesTable + "." + "Url"
-	ArticlesTitle          = ArticlesTable + "." + "Title"
-	ArticlesDiscoveryTime  = ArticlesTable + "." + "DiscoveryTime"
-	ArticlesLastScrapeTime = ArticlesTable + "." + "LastScrapeTime"
+	ArticlesID              = ArticlesTable + "." + "ID"
+	ArticlesSiteName        = ArticlesTable + "." + SiteNameSuffix
+	ArticlesUrl             = ArticlesTable + "." + "Url"
+	ArticlesTitle           = ArticlesTable + "." + "Title"
+	ArticlesDiscoveryTime   = ArticlesTable + "." + "DiscoveryTime"
+	ArticlesLastScrapeTime  = ArticlesTable + "." + "LastScrapeTime"
+	ArticlesDiscoverySource = ArticlesTable + "." + "DiscoverySource"
+	ArticlesCommentCount    = ArticlesTable + "." + "CommentCount"
+	ArticlesVoteCount       = ArticlesTable + "." + "VoteCount"
+	ArticlesScrapeInterval  = ArticlesTable + "." + "ScrapeIntervalSeconds"
+	ArticlesLastActivity    = ArticlesTable + "." + "LastActivityTime"
+	ArticlesState           = ArticlesTable + "." + "State"
+
+	RunsID            = RunsTable + "." + "ID"
+	RunsKind          = RunsTable + "." + "Kind"
+	RunsSiteName      = RunsTable + "." + SiteNameSuffix
+	RunsParams        = RunsTable + "." + "Params"
+	RunsStatus        = RunsTable + "." + "Status"
+	RunsCheckpoint    = RunsTable + "." + "Checkpoint"
+	RunsArticlesFound = RunsTable + "." + "ArticlesFound"
+	RunsCommentsFound = RunsTable + "." + "CommentsFound"
+	RunsError         = RunsTable + "." + "Error"
+	RunsStartTime     = RunsTable + "." + "StartTime"
+	RunsUpdateTime    = RunsTable + "." + "UpdateTime"
+	RunsFinishTime    = RunsTable + "." + "FinishTime"
 
-	UsersID      = UsersTable + "." + "ID"
-	UsersName    = UsersTable + "." + "Name"
-	UserLikes    = "UserLikes"
-	UserDislikes = "UserDislikes"
-	UserScore    = "UserScore"
+	UsersID                  = UsersTable + "." + "ID"
+	UsersName                = UsersTable + "." + "Name"
+	UsersJoinTime            = UsersTable + "." + "JoinTime"
+	UsersAvatarURL           = UsersTable + "." + "AvatarURL"
+	UsersProfileCommentCount = UsersTable + "." + "ProfileCommentCount"
+	UsersProfileScrapeTime   = UsersTable + "." + "ProfileScrapeTime"
+	UserLikes                = "UserLikes"
+	UserDislikes             = "UserDislikes"
+	UserScore                = "UserScore"
 
 	SiteLikes    = "SiteLikes"
 	SiteDislikes = "SiteDislikes"
@@ -53,4 +80,12 @@ const (
 	NewAliasSiteName = NewAlias + "." + SiteNameSuffix
 
 	OldAlias = "OldAlias"
+
+	LeasesArticleID = LeasesTable + "." + "ArticleID"
+	LeasesOwner     = LeasesTable + "." + "Owner"
+	LeasesExpiry    = LeasesTable + "." + "ExpiryTime"
+
+	QuarantinesSiteName = QuarantinesTable + "." + "SiteName"
+	QuarantinesReason   = QuarantinesTable + "." + "Reason"
+	QuarantinesTime     = QuarantinesTable + "." + "QuarantineTime"
 )
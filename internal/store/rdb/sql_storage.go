@@ -17,6 +17,7 @@ import (
 
 	"github.com/salt-today/salttoday2/internal"
 	"github.com/salt-today/salttoday2/internal/logger"
+	"github.com/salt-today/salttoday2/internal/metrics"
 	"github.com/salt-today/salttoday2/internal/store"
 	"github.com/salt-today/salttoday2/internal/store/rdb/migrations"
 )
@@ -27,6 +28,13 @@ const (
 
 var _ store.Storage = (*sqlStorage)(nil)
 
+var (
+	commentsDeleted = metrics.NewCounterVec("salttoday_store_comments_deleted_total",
+		"Stored comments found to have been deleted from their article")
+	massDeletionsSkipped = metrics.NewCounterVec("salttoday_store_mass_deletions_skipped_total",
+		"Scrapes missing too many comments to mark them deleted")
+)
+
 type sqlStorage struct {
 	db      *sql.DB
 	dialect goqu.DialectWrapper
@@ -190,15 +198,8 @@ func (s *sqlStorage) AddComments(ctx context.Context, comments []*store.Comment)
 func (s *sqlStorage) addCommentsToArticle(ctx context.Context, articleID int, comments []*store.Comment) error {
 	entry := logger.New(ctx).WithField("articleID", articleID)
 
-	// Determine if any comments were deleted
-	queryOpts := &store.CommentQueryOptions{
-		ArticleID: &articleID,
-		PageOpts: &store.PageQueryOptions{
-			Order: store.OrderByBoth,
-		},
-	}
-
-	storedComments, err := s.GetComments(ctx, queryOpts)
+	// Determine if any comments were deleted, comparing against every stored comment rather than a page
+	storedComments, err := s.GetArticleComments(ctx, articleID)
 	if errors.Is(err, &store.NoQueryResultsError{}) {
 		// no-op
 		entry.Info("New article, no comments found")
@@ -212,19 +213,51 @@ func (s *sqlStorage) addCommentsToArticle(ctx context.Context, articleID int, co
 		commentsMap[comment.ID] = comment
 	}
 
+	var missing []*store.Comment
+	live := 0
 	for _, storedComment := range storedComments {
 		if _, ok := commentsMap[storedComment.ID]; !ok {
 			if !storedComment.Deleted {
-				entry.WithField("commentID", storedComment.ID).Info("Found comment was deleted!")
-				storedComment.Deleted = true
-				comments = append(comments, storedComment)
+				missing = append(missing, storedComment)
 			}
 		} else {
 			// Update just incase we found a comment that we thought was deleted before, but we're just bad at scraping
 			commentsMap[storedComment.ID].Deleted = false
 		}
+		if !storedComment.Deleted {
+			live++
+		}
+	}
+
+	// Losing most of an article's comments at once is a broken scrape rather than moderation
+	if store.IsMassDeletion(len(missing), live) {
+		entry.WithFields(logrus.Fields{
+			"missing": len(missing),
+			"stored":  live,
+		}).Warn("Too many comments missing to be deletions, not marking any deleted")
+		massDeletionsSkipped.With().Inc()
+	} else {
+		commentsDeleted.With().Add(float64(len(missing)))
+		for _, storedComment := range missing {
+			entry.WithField("commentID", storedComment.ID).Info("Found comment was deleted!")
+			storedComment.Deleted = true
+			comments = append(comments, storedComment)
+		}
 	}
 
+	return s.upsertComments(ctx, comments)
+}
+
+// UpdateComments stores comments from a scrape that didn't see all of an article's comments,
+// so none are marked deleted
+func (s *sqlStorage) UpdateComments(ctx context.Context, comments []*store.Comment) error {
+	if len(comments) == 0 {
+		return nil
+	}
+	return s.upsertComments(ctx, comments)
+}
+
+func (s *sqlStorage) upsertComments(ctx context.Context, comments []*store.Comment) error {
 	// Upsert comment into database
 	ds := s.dialect.Insert(CommentsTable).
 		Cols(CommentsID, CommentsArticleID, CommentsUserID, CommentsTime, CommentsText, CommentsLikes, CommentsDislikes, CommentsDeleted).
@@ -415,6 +448,42 @@ func (s *sqlStorage) GetComments(ctx context.Context, opts *store.CommentQueryOp
 	return comments, nil
 }
 
+// GetArticleComments returns every comment stored for an article, without paging, for comparing
+// against a fresh scrape. Only the comment's own columns are filled in.
+func (s *sqlStorage) GetArticleComments(ctx context.Context, articleID int) ([]*store.Comment, error) {
+	query, _, err := s.dialect.
+		Select(CommentsID, CommentsArticleID, CommentsUserID, CommentsTime, CommentsText, CommentsLikes, CommentsDislikes, CommentsDeleted).
+		From(CommentsTable).
+		Where(goqu.Ex{CommentsArticleID: articleID}).
+		ToSQL()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var comments []*store.Comment
+	for rows.Next() {
+		c := &store.Comment{}
+		if err := rows.Scan(&c.ID, &c.Article.ID, &c.User.ID, &c.Time, &c.Text, &c.Likes, &c.Dislikes, &c.Deleted); err != nil {
+			return nil, fmt.Errorf("failed to scan comment record: %w", err)
+		}
+		comments = append(comments, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(comments) == 0 {
+		return nil, &store.NoQueryResultsError{}
+	}
+	return comments, nil
+}
+
 func (s *sqlStorage) AddArticles(ctx context.Context, articles ...*store.Article) error {
 	// If no articles to add, return early
 	if len(articles) == 0 {
@@ -426,11 +495,11 @@ func (s *sqlStorage) AddArticles(ctx context.Context, articles ...*store.Article
 
 	// Use INSERT IGNORE to handle duplicate article IDs gracefully
 	// This will insert new articles and skip duplicates without failing
-	ds := s.dialect.Insert(ArticlesTable).Cols(ArticlesID, ArticlesSiteName, ArticlesUrl, ArticlesTitle, ArticlesDiscoveryTime, ArticlesLastScrapeTime)
+	ds := s.dialect.Insert(ArticlesTable).Cols(ArticlesID, ArticlesSiteName, ArticlesUrl, ArticlesTitle, ArticlesDiscoveryTime, ArticlesLastScrapeTime, ArticlesDiscoverySource)
 
 	// We want to set the lastScrapedTime to nil so that the article will be scraped immediately
 	for _, article := range articles {
-		ds = ds.Vals(goqu.Vals{article.ID, article.SiteName, article.Url, article.Title, article.DiscoveryTime, nil})
+		ds = ds.Vals(goqu.Vals{article.ID, article.SiteName, article.Url, article.Title, article.DiscoveryTime, nil, article.DiscoverySource})
 	}
 
 	// Generate SQL with INSERT IGNORE to handle duplicates
@@ -465,7 +534,7 @@ func (s *sqlStorage) AddArticles(ctx context.Context, articles ...*store.Article
 
 func (s *sqlStorage) GetArticles(ctx context.Context, ids ...int) ([]*store.Article, error) {
 	sd := s.dialect.
-		Select(ArticlesID, ArticlesUrl, ArticlesTitle, ArticlesDiscoveryTime, ArticlesLastScrapeTime).
+		Select(articleColumns...).
 		From(ArticlesTable).
 		Where(goqu.Ex{ArticlesID: ids})
 
@@ -572,28 +641,41 @@ func addPaging(sd *goqu.SelectDataset, pageOpts *store.PageQueryOptions) *goqu.S
 	return sd
 }
 
+// articleColumns are the columns hydrateArticles expects, in order
+var articleColumns = []any{
+	ArticlesID, ArticlesUrl, ArticlesTitle, ArticlesDiscoveryTime, ArticlesLastScrapeTime,
+	ArticlesCommentCount, ArticlesVoteCount, ArticlesScrapeInterval, ArticlesLastActivity, ArticlesState,
+}
+
 func hydrateArticles(rows *sql.Rows) ([]*store.Article, error) {
 	articles := make([]*store.Article, 0)
-	var id int
-	var url, title string
-	var first, last sql.NullTime
+	var id, commentCount, voteCount, intervalSeconds int
+	var url, title, state string
+	var first, last, activity sql.NullTime
 	for rows.Next() {
-		err := rows.Scan(&id, &url, &title, &first, &last)
+		err := rows.Scan(&id, &url, &title, &first, &last, &commentCount, &voteCount, &intervalSeconds, &activity, &state)
 		if err != nil {
 			return nil, err
 		}
 
 		article := &store.Article{
-			ID:            id,
-			Url:           url,
-			Title:         title,
-			DiscoveryTime: first.Time.Local(),
+			ID:             id,
+			Url:            url,
+			Title:          title,
+			DiscoveryTime:  first.Time.Local(),
+			CommentCount:   commentCount,
+			VoteCount:      voteCount,
+			ScrapeInterval: time.Duration(intervalSeconds) * time.Second,
+			State:          state,
 		}
 
 		if last.Valid {
 			localTime := last.Time.Local()
 			article.LastScrapeTime = localTime
 		}
+		if activity.Valid {
+			article.LastActivityTime = activity.Time.Local()
+		}
 
 		articles = append(articles, article)
 	}
@@ -613,7 +695,7 @@ func (s *sqlStorage) GetRecentlyDiscoveredArticles(ctx context.Context, threshol
 	thresholdUTC := threshold.UTC().Truncate(time.Second)
 
 	sd := s.dialect.
-		Select(ArticlesID, ArticlesUrl, ArticlesTitle, ArticlesDiscoveryTime, ArticlesLastScrapeTime).
+		Select(articleColumns...).
 		From(ArticlesTable).
 		Where(
 			goqu.Ex{
@@ -653,6 +735,44 @@ func (s *sqlStorage) SetArticleScrapedAt(ctx context.Context, scrapedTime time.T
 	return err
 }
 
+func (s *sqlStorage) SetArticleActivity(ctx context.Context, articles ...*store.Article) error {
+	for _, article := range articles {
+		ds := s.dialect.Update(ArticlesTable).
+			Where(goqu.Ex{ArticlesID: article.ID}).
+			Set(goqu.Record{
+				ArticlesLastScrapeTime: nullTime(article.LastScrapeTime),
+				ArticlesCommentCount:   article.CommentCount,
+				ArticlesVoteCount:      article.VoteCount,
+				ArticlesScrapeInterval: int(article.ScrapeInterval / time.Second),
+				ArticlesLastActivity:   nullTime(article.LastActivityTime),
+			})
+
+		query, _, err := ds.ToSQL()
+		if err != nil {
+			return err
+		}
+
+		if _, err := s.db.ExecContext(ctx, query); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *sqlStorage) SetArticleState(ctx context.Context, state string, articleIDs ...int) error {
+	ds := s.dialect.Update(ArticlesTable).
+		Where(goqu.Ex{ArticlesID: articleIDs}).
+		Set(goqu.Record{ArticlesState: state})
+
+	query, _, err := ds.ToSQL()
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.ExecContext(ctx, query)
+	return err
+}
+
 func (s *sqlStorage) GetStats(ctx context.Context) (*store.Stats, error) {
 	stats := &store.Stats{}
 	sd := s.dialect.Select(goqu.COUNT(CommentsID)).From(CommentsTable)
@@ -728,6 +848,395 @@ func (s *sqlStorage) GetStats(ctx context.Context) (*store.Stats, error) {
 	return stats, nil
 }
 
+func (s *sqlStorage) AddScrapeRun(ctx context.Context, run *store.ScrapeRun) error {
+	ds := s.dialect.Insert(RunsTable).Rows(goqu.Record{
+		RunsKind:          run.Kind,
+		RunsSiteName:      run.SiteName,
+		RunsParams:        run.Params,
+		RunsStatus:        run.Status,
+		RunsCheckpoint:    run.Checkpoint,
+		RunsArticlesFound: run.ArticlesFound,
+		RunsCommentsFound: run.CommentsFound,
+		RunsError:         run.Error,
+		RunsStartTime:     run.StartTime.UTC().Truncate(time.Second),
+		RunsUpdateTime:    run.UpdateTime.UTC().Truncate(time.Second),
+		RunsFinishTime:    nullTime(run.FinishTime),
+	})
+
+	query, _, err := ds.ToSQL()
+	if err != nil {
+		return err
+	}
+
+	result, err := s.db.ExecContext(ctx, query)
+	if err != nil {
+		return err
+	}
+
+	run.ID, err = result.LastInsertId()
+	return err
+}
+
+func (s *sqlStorage) UpdateScrapeRun(ctx context.Context, run *store.ScrapeRun) error {
+	ds := s.dialect.Update(RunsTable).
+		Where(goqu.Ex{RunsID: run.ID}).
+		Set(goqu.Record{
+			RunsStatus:        run.Status,
+			RunsCheckpoint:    run.Checkpoint,
+			RunsArticlesFound: run.ArticlesFound,
+			RunsCommentsFound: run.CommentsFound,
+			RunsError:         run.Error,
+			RunsUpdateTime:    run.UpdateTime.UTC().Truncate(time.Second),
+			RunsFinishTime:    nullTime(run.FinishTime),
+		})
+
+	query, _, err := ds.ToSQL()
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.ExecContext(ctx, query)
+	return err
+}
+
+func (s *sqlStorage) GetScrapeRuns(ctx context.Context, opts *store.ScrapeRunQueryOptions) ([]*store.ScrapeRun, error) {
+	sd := s.dialect.
+		Select(RunsID, RunsKind, RunsSiteName, RunsParams, RunsStatus, RunsCheckpoint, RunsArticlesFound, RunsCommentsFound, RunsError, RunsStartTime, RunsUpdateTime, RunsFinishTime).
+		From(RunsTable).
+		Order(goqu.I(RunsID).Desc())
+
+	if opts.Kind != "" {
+		sd = sd.Where(goqu.Ex{RunsKind: opts.Kind})
+	}
+	if opts.SiteName != "" {
+		sd = sd.Where(goqu.Ex{RunsSiteName: opts.SiteName})
+	}
+	if opts.Params != "" {
+		sd = sd.Where(goqu.Ex{RunsParams: opts.Params})
+	}
+	if opts.Unfinished {
+		sd = sd.Where(goqu.Ex{RunsStatus: goqu.Op{"neq": store.RunStatusCompleted}})
+	}
+	if opts.PageOpts != nil {
+		sd = addPaging(sd, opts.PageOpts)
+	}
+
+	query, _, err := sd.ToSQL()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	runs := make([]*store.ScrapeRun, 0)
+	for rows.Next() {
+		run := &store.ScrapeRun{}
+		var runErr sql.NullString
+		var finished sql.NullTime
+		err := rows.Scan(&run.ID, &run.Kind, &run.SiteName, &run.Params, &run.Status, &run.Checkpoint, &run.ArticlesFound, &run.CommentsFound, &runErr, &run.StartTime, &run.UpdateTime, &finished)
+		if err != nil {
+			return nil, err
+		}
+		run.Error = runErr.String
+		run.StartTime = run.StartTime.Local()
+		run.UpdateTime = run.UpdateTime.Local()
+		if finished.Valid {
+			run.FinishTime = finished.Time.Local()
+		}
+		runs = append(runs, run)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(runs) == 0 {
+		return nil, &store.NoQueryResultsError{}
+	}
+
+	return runs, nil
+}
+
+// ClaimArticles leases the articles that are free, expired or already held by owner until expiry,
+// returning the IDs that owner now holds
+func (s *sqlStorage) ClaimArticles(ctx context.Context, owner string, expiry time.Time, articleIDs ...int) ([]int, error) {
+	if len(articleIDs) == 0 {
+		return nil, nil
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	expiryUTC := expiry.UTC().Truncate(time.Second)
+	statements := []interface{ ToSQL() (string, []any, error) }{
+		// Leases left behind by crashed scrapers are up for grabs
+		s.dialect.Delete(LeasesTable).Where(
+			goqu.Ex{LeasesArticleID: articleIDs},
+			goqu.I(LeasesExpiry).Lt(time.Now().UTC().Truncate(time.Second)),
+		),
+		// Owner's own leases are extended
+		s.dialect.Update(LeasesTable).
+			Where(goqu.Ex{LeasesArticleID: articleIDs, LeasesOwner: owner}).
+			Set(goqu.Record{LeasesExpiry: expiryUTC}),
+	}
+	for _, sd := range statements {
+		query, _, err := sd.ToSQL()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := tx.ExecContext(ctx, query); err != nil {
+			return nil, err
+		}
+	}
+
+	// Free articles are inserted, the primary key keeps out those another scraper holds
+	ds := s.dialect.Insert(LeasesTable).Cols(LeasesArticleID, LeasesOwner, LeasesExpiry)
+	for _, id := range articleIDs {
+		ds = ds.Vals(goqu.Vals{id, owner, expiryUTC})
+	}
+	query, _, err := ds.ToSQL()
+	if err != nil {
+		return nil, err
+	}
+	query = strings.Replace(query, "INSERT INTO", "INSERT IGNORE INTO", 1)
+	if _, err := tx.ExecContext(ctx, query); err != nil {
+		return nil, err
+	}
+
+	query, _, err = s.dialect.Select(LeasesArticleID).From(LeasesTable).
+		Where(goqu.Ex{LeasesArticleID: articleIDs, LeasesOwner: owner}).ToSQL()
+	if err != nil {
+		return nil, err
+	}
+	rows, err := tx.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var claimed []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		claimed = append(claimed, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return claimed, tx.Commit()
+}
+
+// RenewArticleLeases extends owner's leases on the articles until expiry, returning the IDs that
+// owner still holds. Those missing were taken over by another scraper after expiring.
+func (s *sqlStorage) RenewArticleLeases(ctx context.Context, owner string, expiry time.Time, articleIDs ...int) ([]int, error) {
+	if len(articleIDs) == 0 {
+		return nil, nil
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	query, _, err := s.dialect.Update(LeasesTable).
+		Where(goqu.Ex{LeasesArticleID: articleIDs, LeasesOwner: owner}).
+		Set(goqu.Record{LeasesExpiry: expiry.UTC().Truncate(time.Second)}).
+		ToSQL()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tx.ExecContext(ctx, query); err != nil {
+		return nil, err
+	}
+
+	query, _, err = s.dialect.Select(LeasesArticleID).From(LeasesTable).
+		Where(goqu.Ex{LeasesArticleID: articleIDs, LeasesOwner: owner}).ToSQL()
+	if err != nil {
+		return nil, err
+	}
+	rows, err := tx.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var held []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		held = append(held, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return held, tx.Commit()
+}
+
+// ReleaseArticles gives up owner's leases on the articles
+func (s *sqlStorage) ReleaseArticles(ctx context.Context, owner string, articleIDs ...int) error {
+	ds := s.dialect.Delete(LeasesTable).
+		Where(goqu.Ex{LeasesArticleID: articleIDs, LeasesOwner: owner})
+
+	query, _, err := ds.ToSQL()
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.ExecContext(ctx, query)
+	return err
+}
+
+// QuarantineSite records that a site's comments shouldn't be stored, keeping the first reason
+// if it's already quarantined
+func (s *sqlStorage) QuarantineSite(ctx context.Context, siteName, reason string, quarantineTime time.Time) error {
+	query, _, err := s.dialect.Insert(QuarantinesTable).
+		Cols(QuarantinesSiteName, QuarantinesReason, QuarantinesTime).
+		Vals(goqu.Vals{siteName, reason, quarantineTime.UTC().Truncate(time.Second)}).
+		ToSQL()
+	if err != nil {
+		return err
+	}
+	query = strings.Replace(query, "INSERT INTO", "INSERT IGNORE INTO", 1)
+
+	_, err = s.db.ExecContext(ctx, query)
+	return err
+}
+
+// GetQuarantinedSites returns the reason each quarantined site was quarantined, keyed by site name
+func (s *sqlStorage) GetQuarantinedSites(ctx context.Context) (map[string]string, error) {
+	query, _, err := s.dialect.Select(QuarantinesSiteName, QuarantinesReason).From(QuarantinesTable).ToSQL()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sites := make(map[string]string)
+	for rows.Next() {
+		var site, reason string
+		if err := rows.Scan(&site, &reason); err != nil {
+			return nil, fmt.Errorf("failed to scan quarantine record: %w", err)
+		}
+		sites[site] = reason
+	}
+	return sites, rows.Err()
+}
+
+// ClearSiteQuarantine lets a site's comments be stored again
+func (s *sqlStorage) ClearSiteQuarantine(ctx context.Context, siteName string) error {
+	query, _, err := s.dialect.Delete(QuarantinesTable).Where(goqu.Ex{QuarantinesSiteName: siteName}).ToSQL()
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.ExecContext(ctx, query)
+	return err
+}
+
+// GetUsersToProfile returns users whose profile hasn't been scraped since scrapedBefore, those never
+// scraped first, along with how many of their comments are stored and an article they commented on
+func (s *sqlStorage) GetUsersToProfile(ctx context.Context, scrapedBefore time.Time, limit uint) ([]*store.User, error) {
+	sd := s.dialect.
+		Select(UsersID, UsersName, UsersProfileScrapeTime, goqu.COUNT(CommentsID), goqu.MAX(ArticlesUrl)).
+		From(UsersTable).
+		InnerJoin(goqu.T(CommentsTable), goqu.On(goqu.I(UsersID).Eq(goqu.I(CommentsUserID)))).
+		InnerJoin(goqu.T(ArticlesTable), goqu.On(goqu.I(CommentsArticleID).Eq(goqu.I(ArticlesID)))).
+		Where(goqu.Or(
+			goqu.I(UsersProfileScrapeTime).IsNull(),
+			goqu.I(UsersProfileScrapeTime).Lt(scrapedBefore.UTC()),
+		)).
+		GroupBy(UsersID, UsersName, UsersProfileScrapeTime).
+		Order(goqu.I(UsersProfileScrapeTime).Asc()). // MySQL sorts NULL first
+		Limit(limit)
+
+	query, _, err := sd.ToSQL()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []*store.User
+	for rows.Next() {
+		user := &store.User{}
+		var scraped sql.NullTime
+		if err := rows.Scan(&user.ID, &user.UserName, &scraped, &user.CommentCount, &user.ArticleURL); err != nil {
+			return nil, fmt.Errorf("failed to scan user record: %w", err)
+		}
+		user.ProfileScrapeTime = scraped.Time
+		users = append(users, user)
+	}
+	return users, rows.Err()
+}
+
+// SetUserProfiles records what was scraped from each user's profile page
+func (s *sqlStorage) SetUserProfiles(ctx context.Context, users ...*store.User) error {
+	for _, user := range users {
+		ds := s.dialect.Update(UsersTable).
+			Where(goqu.Ex{UsersID: user.ID}).
+			Set(goqu.Record{
+				UsersJoinTime:            nullTime(user.JoinTime),
+				UsersAvatarURL:           user.AvatarURL,
+				UsersProfileCommentCount: user.ProfileCommentCount,
+				UsersProfileScrapeTime:   nullTime(user.ProfileScrapeTime),
+			})
+
+		query, _, err := ds.ToSQL()
+		if err != nil {
+			return err
+		}
+
+		if _, err := s.db.ExecContext(ctx, query); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetUserProfileScrapedAt records a profile scrape attempt without changing what's stored from the profile
+func (s *sqlStorage) SetUserProfileScrapedAt(ctx context.Context, scrapedTime time.Time, userIDs ...int) error {
+	ds := s.dialect.Update(UsersTable).
+		Where(goqu.Ex{UsersID: userIDs}).
+		Set(goqu.Record{UsersProfileScrapeTime: nullTime(scrapedTime)})
+
+	query, _, err := ds.ToSQL()
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.ExecContext(ctx, query)
+	return err
+}
+
+// nullTime stores zero times as NULL
+func nullTime(t time.Time) any {
+	if t.IsZero() {
+		return nil
+	}
+	return t.UTC().Truncate(time.Second)
+}
+
 func (s *sqlStorage) shutdown() error {
 	return s.db.Close()
 }
@@ -33,3 +33,181 @@ func TestAddArticle(t *testing.T) {
 	err = s.AddArticles(context.Background(), articles...)
 	require.NoError(t, err)
 }
+
+func TestScrapeRuns(t *testing.T) {
+	s, err := New(context.Background())
+	require.NoError(t, err)
+
+	run := &store.ScrapeRun{
+		Kind:       store.RunKindBackfill,
+		SiteName:   "SooToday",
+		Params:     "ids=1-100",
+		Status:     store.RunStatusRunning,
+		StartTime:  time.Now(),
+		UpdateTime: time.Now(),
+	}
+	require.NoError(t, s.AddScrapeRun(context.Background(), run))
+	require.NotZero(t, run.ID)
+
+	run.Checkpoint = "/local-news/"
+	run.Status = store.RunStatusFailed
+	require.NoError(t, s.UpdateScrapeRun(context.Background(), run))
+
+	runs, err := s.GetScrapeRuns(context.Background(), &store.ScrapeRunQueryOptions{
+		Kind:       store.RunKindBackfill,
+		SiteName:   "SooToday",
+		Params:     "ids=1-100",
+		Unfinished: true,
+	})
+	require.NoError(t, err)
+	require.Equal(t, run.ID, runs[0].ID)
+	require.Equal(t, "/local-news/", runs[0].Checkpoint)
+}
+
+func TestArticleActivity(t *testing.T) {
+	s, err := New(context.Background())
+	require.NoError(t, err)
+
+	article := &store.Article{
+		ID:            2,
+		Title:         "Article 2",
+		Url:           "testurl2",
+		SiteName:      "SooToday",
+		DiscoveryTime: time.Now(),
+	}
+	require.NoError(t, s.AddArticles(context.Background(), article))
+
+	article.LastScrapeTime = time.Now()
+	article.LastActivityTime = article.LastScrapeTime
+	article.CommentCount = 12
+	article.VoteCount = 40
+	article.ScrapeInterval = 30 * time.Minute
+	require.NoError(t, s.SetArticleActivity(context.Background(), article))
+
+	articles, err := s.GetArticles(context.Background(), article.ID)
+	require.NoError(t, err)
+	require.Equal(t, 12, articles[0].CommentCount)
+	require.Equal(t, 40, articles[0].VoteCount)
+	require.Equal(t, 30*time.Minute, articles[0].ScrapeInterval)
+	require.False(t, articles[0].LastActivityTime.IsZero())
+	require.Empty(t, articles[0].State)
+
+	require.NoError(t, s.SetArticleState(context.Background(), store.ArticleStateRemoved, article.ID))
+	articles, err = s.GetArticles(context.Background(), article.ID)
+	require.NoError(t, err)
+	require.Equal(t, store.ArticleStateRemoved, articles[0].State)
+}
+
+func TestArticleLeases(t *testing.T) {
+	s, err := New(context.Background())
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	claimed, err := s.ClaimArticles(ctx, "scraper-a", time.Now().Add(time.Minute), 10, 11)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []int{10, 11}, claimed)
+
+	// Another scraper only gets what's free
+	claimed, err = s.ClaimArticles(ctx, "scraper-b", time.Now().Add(time.Minute), 11, 12)
+	require.NoError(t, err)
+	require.Equal(t, []int{12}, claimed)
+
+	// Once released, or expired, leases can be taken over
+	require.NoError(t, s.ReleaseArticles(ctx, "scraper-a", 11))
+	held, err := s.RenewArticleLeases(ctx, "scraper-a", time.Now().Add(-time.Minute), 10, 11)
+	require.NoError(t, err)
+	require.Equal(t, []int{10}, held)
+	claimed, err = s.ClaimArticles(ctx, "scraper-b", time.Now().Add(time.Minute), 10, 11)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []int{10, 11}, claimed)
+
+	// Renewing reports the leases that were taken over
+	held, err = s.RenewArticleLeases(ctx, "scraper-a", time.Now().Add(time.Minute), 10)
+	require.NoError(t, err)
+	require.Empty(t, held)
+
+	require.NoError(t, s.ReleaseArticles(ctx, "scraper-b", 10, 11, 12))
+}
+
+func TestAddCommentsComparesEveryStoredComment(t *testing.T) {
+	s, err := New(context.Background())
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	require.NoError(t, s.AddArticles(ctx, &store.Article{ID: 30, Url: "https://www.sootoday.com/local-news/busy-article-30", SiteName: "SooToday"}))
+	require.NoError(t, s.AddUsers(ctx, &store.User{ID: 901, UserName: "busy"}))
+
+	// More comments than a page of GetComments holds
+	var comments []*store.Comment
+	for i := range 30 {
+		comments = append(comments, &store.Comment{ID: 30001 + i, Article: store.Article{ID: 30}, User: store.User{ID: 901}, Time: time.Now(), Likes: int32(30 - i)})
+	}
+	require.NoError(t, s.AddComments(ctx, comments))
+
+	stored, err := s.GetArticleComments(ctx, 30)
+	require.NoError(t, err)
+	require.Len(t, stored, 30)
+
+	// The lowest scoring comment, outside the top page, is still found deleted
+	require.NoError(t, s.AddComments(ctx, comments[:29]))
+	stored, err = s.GetArticleComments(ctx, 30)
+	require.NoError(t, err)
+	for _, comment := range stored {
+		require.Equal(t, comment.ID == 30030, comment.Deleted, comment.ID)
+	}
+}
+
+func TestSiteQuarantines(t *testing.T) {
+	s, err := New(context.Background())
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	require.NoError(t, s.QuarantineSite(ctx, "QuarantineToday", "parser drifted", time.Now()))
+	require.NoError(t, s.QuarantineSite(ctx, "QuarantineToday", "drifted again", time.Now()))
+	sites, err := s.GetQuarantinedSites(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "parser drifted", sites["QuarantineToday"])
+
+	require.NoError(t, s.ClearSiteQuarantine(ctx, "QuarantineToday"))
+	sites, err = s.GetQuarantinedSites(ctx)
+	require.NoError(t, err)
+	require.NotContains(t, sites, "QuarantineToday")
+}
+
+func TestUserProfiles(t *testing.T) {
+	s, err := New(context.Background())
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	require.NoError(t, s.AddArticles(ctx, &store.Article{ID: 20, Url: "https://www.sootoday.com/local-news/profile-test-20", SiteName: "SooToday"}))
+	require.NoError(t, s.AddUsers(ctx, &store.User{ID: 900, UserName: "profiled"}))
+	require.NoError(t, s.AddComments(ctx, []*store.Comment{
+		{ID: 9001, Article: store.Article{ID: 20}, User: store.User{ID: 900}, Time: time.Now()},
+		{ID: 9002, Article: store.Article{ID: 20}, User: store.User{ID: 900}, Time: time.Now()},
+	}))
+
+	users, err := s.GetUsersToProfile(ctx, time.Now(), 1000)
+	require.NoError(t, err)
+	var user *store.User
+	for _, u := range users {
+		if u.ID == 900 {
+			user = u
+		}
+	}
+	require.NotNil(t, user)
+	require.Equal(t, 2, user.CommentCount)
+	require.Equal(t, "https://www.sootoday.com/local-news/profile-test-20", user.ArticleURL)
+
+	// Freshly scraped profiles aren't due again
+	user.JoinTime = time.Date(2019, 3, 1, 0, 0, 0, 0, time.UTC)
+	user.AvatarURL = "https://www.sootoday.com/avatars/900.png"
+	user.ProfileCommentCount = 3
+	user.ProfileScrapeTime = time.Now()
+	require.NoError(t, s.SetUserProfiles(ctx, user))
+
+	users, err = s.GetUsersToProfile(ctx, time.Now().Add(-time.Hour), 1000)
+	require.NoError(t, err)
+	for _, u := range users {
+		require.NotEqual(t, 900, u.ID)
+	}
+}
@@ -7,7 +7,9 @@ import (
 
 type Storage interface {
 	AddComments(ctx context.Context, comments []*Comment) error
+	UpdateComments(ctx context.Context, comments []*Comment) error // Like AddComments, without marking missing comments deleted
 	GetComments(ctx context.Context, opts *CommentQueryOptions) ([]*Comment, error)
+	GetArticleComments(ctx context.Context, articleID int) ([]*Comment, error) // Every stored comment, unpaged
 	AddArticles(ctx context.Context, articles ...*Article) error
 	GetArticles(ctx context.Context, articleIDs ...int) ([]*Article, error)
 	GetRecentlyDiscoveredArticles(ctx context.Context, threshold time.Time) ([]*Article, error)
@@ -17,6 +19,35 @@ type Storage interface {
 	GetTopSite(ctx context.Context, orderBy int) (*Site, error)
 	GetStats(ctx context.Context) (*Stats, error)
 	SetArticleScrapedAt(ctx context.Context, scrapedTime time.Time, articleIDs ...int) error
+	SetArticleActivity(ctx context.Context, articles ...*Article) error
+	SetArticleState(ctx context.Context, state string, articleIDs ...int) error
+	AddScrapeRun(ctx context.Context, run *ScrapeRun) error
+	UpdateScrapeRun(ctx context.Context, run *ScrapeRun) error
+	GetScrapeRuns(ctx context.Context, opts *ScrapeRunQueryOptions) ([]*ScrapeRun, error)
+
+	// Article leases let several scrapers split the work, each only scraping the articles it holds
+	ClaimArticles(ctx context.Context, owner string, expiry time.Time, articleIDs ...int) ([]int, error)
+	RenewArticleLeases(ctx context.Context, owner string, expiry time.Time, articleIDs ...int) ([]int, error)
+	ReleaseArticles(ctx context.Context, owner string, articleIDs ...int) error
+
+	// Sites whose parser drifted stay quarantined across runs until they're cleared
+	QuarantineSite(ctx context.Context, siteName, reason string, quarantineTime time.Time) error
+	GetQuarantinedSites(ctx context.Context) (map[string]string, error) // Reasons, keyed by site name
+	ClearSiteQuarantine(ctx context.Context, siteName string) error
+
+	// Commenter profiles are scraped slowly, oldest first
+	GetUsersToProfile(ctx context.Context, scrapedBefore time.Time, limit uint) ([]*User, error)
+	SetUserProfiles(ctx context.Context, users ...*User) error
+	SetUserProfileScrapedAt(ctx context.Context, scrapedTime time.Time, userIDs ...int) error
+}
+
+// MassDeletionMinimum is the fewest missing comments that can look like a broken scrape
+const MassDeletionMinimum = 5
+
+// IsMassDeletion reports whether an article missing this many of its live stored comments is a
+// broken scrape rather than moderation, in which case none of them should be marked deleted
+func IsMassDeletion(missing, live int) bool {
+	return missing >= MassDeletionMinimum && missing*2 > live
 }
 
 const (
@@ -45,6 +76,15 @@ type CommentQueryOptions struct {
 	PageOpts *PageQueryOptions
 }
 
+type ScrapeRunQueryOptions struct {
+	Kind       string
+	SiteName   string
+	Params     string
+	Unfinished bool
+
+	PageOpts *PageQueryOptions
+}
+
 type UserQueryOptions struct {
 	ID   *int
 	Name string
@@ -17,20 +17,80 @@ type Comment struct {
 }
 
 type Article struct {
-	ID             int
-	Title          string
-	SiteName       string
-	Url            string
-	DiscoveryTime  time.Time
-	LastScrapeTime time.Time
+	ID              int
+	Title           string
+	SiteName        string
+	Url             string
+	DiscoveryTime   time.Time
+	LastScrapeTime  time.Time
+	DiscoverySource string
+	State           string // Empty while the article is up and taking comments
+
+	// Activity seen by the last scrape, used to adapt how often the article is scraped
+	CommentCount     int
+	VoteCount        int           // Likes and dislikes across every comment
+	ScrapeInterval   time.Duration // Zero until the first scrape
+	LastActivityTime time.Time     // Last scrape that saw new comments or votes
+}
+
+// Where an article was first discovered
+const (
+	DiscoverySourceHomepage = "homepage"
+	DiscoverySourceSitemap  = "sitemap"
+	DiscoverySourceFeed     = "feed"
+	DiscoverySourceCategory = "category"
+)
+
+// Why an article is no longer scraped
+const (
+	ArticleStateRemoved        = "removed"
+	ArticleStateCommentsClosed = "comments_closed"
+)
+
+// ScrapeRun is an entry in the run ledger, recording a long-running scrape such as a backfill
+type ScrapeRun struct {
+	ID            int64
+	Kind          string
+	SiteName      string
+	Params        string // Identifies the run's range so it can be resumed
+	Status        string
+	Checkpoint    string // Last completed unit of work, meaning depends on Kind
+	ArticlesFound int
+	CommentsFound int
+	Error         string
+	StartTime     time.Time
+	UpdateTime    time.Time
+	FinishTime    time.Time
 }
 
+// Kinds of scrape run
+const (
+	RunKindBackfill = "backfill"
+)
+
+// Scrape run statuses
+const (
+	RunStatusRunning   = "running"
+	RunStatusCompleted = "completed"
+	RunStatusFailed    = "failed"
+)
+
 type User struct {
 	ID            int
 	UserName      string
 	TotalLikes    int32
 	TotalDislikes int32
 	TotalScore    int32
+
+	// From the user's profile page, zero until it's been scraped
+	JoinTime            time.Time
+	AvatarURL           string
+	ProfileCommentCount int // Comments the profile says the user has made, across every site
+	ProfileScrapeTime   time.Time
+
+	// Set on users due a profile scrape
+	CommentCount int    // Comments stored for the user
+	ArticleURL   string // An article the user commented on, for finding the site their profile is on
 }
 
 type Site struct {